@@ -0,0 +1,359 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package main
+
+// Headless commands, for use in scripts. They never touch the terminal.
+// Files named "-" (or left out) are stdin and stdout. The exit code is 0 on
+// success, 1 if reading, converting or writing failed, and 2 for usage
+// errors.
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/asig/termdraw/pkg/termdraw"
+)
+
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// streams are where a command reads its input and writes its output and
+// errors, if it doesn't use files.
+type streams struct {
+	in       io.Reader
+	out, err io.Writer
+}
+
+var stdStreams = streams{os.Stdin, os.Stdout, os.Stderr}
+
+type command struct {
+	args string
+	help string
+	run  func(s streams, args []string) int
+}
+
+// Set up in init, as the commands refer to the table for their usage
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"convert": {"[-to format] [-o output] [input]",
+			"convert a drawing to another format; the format defaults to the output's extension", runConvert},
+		"render": {"[-colors 24|256|16] [input]",
+			"write a drawing with its colors to stdout, for viewing it in a terminal", runRender},
+		"normalize": {"[-o output | -w] [input]",
+			"rewrite a drawing in its own format, with LF line endings and without trailing blanks", runNormalize},
+	}
+}
+
+// Formats that are not exports, but the formats termdraw saves in
+var saveFormats = []exportFormat{
+	{"text", "Plain text", writeText},
+	{termdraw.DocumentExt[1:], "termdraw document", func(w io.Writer) error { return canvas.WriteDocument(w) }},
+}
+
+var formatExts = map[string]string{
+	".txt":               "text",
+	termdraw.DocumentExt: termdraw.DocumentExt[1:],
+	".ans":               "ansi",
+	".html":              "html",
+	".htm":               "html",
+	".svg":               "svg",
+	".png":               "png",
+}
+
+func usage(fs *flag.FlagSet) {
+	out := fs.Output()
+	fmt.Fprintf(out, "Usage:\n  %s [flags] [filename]\n  %s -html [-html-classes] filename\n", os.Args[0], os.Args[0])
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cmd := commands[name]
+		fmt.Fprintf(out, "  %s %s %s\n    \t%s\n", os.Args[0], name, cmd.args, cmd.help)
+	}
+	fmt.Fprintf(out, "\nFormats: %s\n\nFlags:\n", strings.Join(formatIDs(), ", "))
+	fs.PrintDefaults()
+}
+
+// runCLI handles the command line arguments args, without the program's
+// name. It returns the exit code of a headless command, or edit set and the
+// file to open if the editor should be started.
+func runCLI(s streams, args []string) (code int, edit bool, filename string) {
+	if len(args) > 0 {
+		if cmd, ok := commands[args[0]]; ok {
+			return cmd.run(s, args[1:]), false, ""
+		}
+	}
+
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	fs.SetOutput(s.err)
+	fs.Usage = func() { usage(fs) }
+	htmlOut := fs.Bool("html", false, "write the file as HTML <pre> element to stdout instead of editing it")
+	htmlClasses := fs.Bool("html-classes", false, "with -html, use CSS classes instead of inline styles")
+	if err := fs.Parse(args); err != nil {
+		return parseError(err), false, ""
+	}
+
+	if *htmlOut {
+		if fs.NArg() != 1 {
+			return usageError(s, "-html", "needs exactly one file"), false, ""
+		}
+		return exportHTML(s, fs.Arg(0), *htmlClasses), false, ""
+	}
+	return exitOK, true, fs.Arg(0)
+}
+
+func allFormats() []exportFormat {
+	return append(append([]exportFormat{}, saveFormats...), exportFormats...)
+}
+
+func formatIDs() []string {
+	var ids []string
+	for _, f := range allFormats() {
+		ids = append(ids, f.id)
+	}
+	return ids
+}
+
+func findFormat(id string) (exportFormat, bool) {
+	for _, f := range allFormats() {
+		if f.id == id {
+			return f, true
+		}
+	}
+	return exportFormat{}, false
+}
+
+// parseArgs parses fs' flags, which may be mixed with positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func newFlagSet(s streams, name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(s.err)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s %s\n", os.Args[0], name, commands[name].args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func usageError(s streams, name string, format string, a ...interface{}) int {
+	fmt.Fprintf(s.err, "%s: %s\n", name, fmt.Sprintf(format, a...))
+	return exitUsage
+}
+
+func parseError(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	return exitUsage
+}
+
+func isStdio(filename string) bool {
+	return filename == "" || filename == "-"
+}
+
+// readData returns the content of filename, or of stdin.
+func readData(s streams, filename string) ([]byte, error) {
+	if isStdio(filename) {
+		return ioutil.ReadAll(s.in)
+	}
+	return ioutil.ReadFile(filename)
+}
+
+// readInput loads filename, or stdin, into a new canvas. It returns the
+// data read.
+func readInput(s streams, filename string) ([]byte, error) {
+	data, err := readData(s, filename)
+	if err != nil {
+		return nil, err
+	}
+	canvas = termdraw.NewCanvas(0, 0, 0, 0)
+	return data, readCanvas(data, filename)
+}
+
+// writeOutput writes to filename, or stdout. Files are only written if
+// export succeeded.
+func writeOutput(s streams, filename string, export func(w io.Writer) error) error {
+	if isStdio(filename) {
+		return export(s.out)
+	}
+	var buf bytes.Buffer
+	if err := export(&buf); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, buf.Bytes(), 0644)
+}
+
+func singleInput(s streams, name string, args []string) (string, int) {
+	if len(args) > 1 {
+		return "", usageError(s, name, "too many input files")
+	}
+	if len(args) == 0 {
+		return "-", exitOK
+	}
+	return args[0], exitOK
+}
+
+func fail(s streams, name string, err error) int {
+	fmt.Fprintf(s.err, "%s: %s\n", name, err)
+	return exitError
+}
+
+func runConvert(s streams, args []string) int {
+	fs := newFlagSet(s, "convert")
+	to := fs.String("to", "", "output format: "+strings.Join(formatIDs(), ", "))
+	out := fs.String("o", "-", "output file")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return parseError(err)
+	}
+	in, code := singleInput(s, "convert", args)
+	if code != exitOK {
+		return code
+	}
+
+	id := *to
+	if id == "" {
+		id = formatExts[strings.ToLower(filepath.Ext(*out))]
+		if id == "" {
+			return usageError(s, "convert", "-to is missing and can't be derived from the output file")
+		}
+	}
+	f, ok := findFormat(id)
+	if !ok {
+		return usageError(s, "convert", "unknown format %q", id)
+	}
+
+	if _, err := readInput(s, in); err != nil {
+		return fail(s, "convert", err)
+	}
+	if err := writeOutput(s, *out, f.export); err != nil {
+		return fail(s, "convert", err)
+	}
+	return exitOK
+}
+
+func runRender(s streams, args []string) int {
+	fs := newFlagSet(s, "render")
+	colors := fs.Int("colors", 24, "number of colors: 24 (bit), 256 or 16")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return parseError(err)
+	}
+	in, code := singleInput(s, "render", args)
+	if code != exitOK {
+		return code
+	}
+
+	var mode termdraw.ColorMode
+	switch *colors {
+	case 24:
+		mode = termdraw.ColorModeTrue
+	case 256:
+		mode = termdraw.ColorMode256
+	case 16:
+		mode = termdraw.ColorMode16
+	default:
+		return usageError(s, "render", "unsupported number of colors %d", *colors)
+	}
+
+	if _, err := readInput(s, in); err != nil {
+		return fail(s, "render", err)
+	}
+	if err := canvas.ExportANSI(s.out, mode); err != nil {
+		return fail(s, "render", err)
+	}
+	return exitOK
+}
+
+func runNormalize(s streams, args []string) int {
+	fs := newFlagSet(s, "normalize")
+	out := fs.String("o", "-", "output file")
+	inPlace := fs.Bool("w", false, "overwrite the input file")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return parseError(err)
+	}
+	in, code := singleInput(s, "normalize", args)
+	if code != exitOK {
+		return code
+	}
+	if *inPlace {
+		if isStdio(in) {
+			return usageError(s, "normalize", "-w needs an input file")
+		}
+		*out = in
+	}
+
+	data, err := readInput(s, in)
+	if err != nil {
+		return fail(s, "normalize", err)
+	}
+
+	export := writeText
+	switch {
+	case termdraw.IsDocument(data):
+		export = canvas.WriteDocument
+	case termdraw.IsANSI(data):
+		export = func(w io.Writer) error { return canvas.ExportANSI(w, termdraw.ColorModeTrue) }
+	}
+	if err := writeOutput(s, *out, export); err != nil {
+		return fail(s, "normalize", err)
+	}
+	return exitOK
+}
+
+// exportHTML implements the -html flag.
+func exportHTML(s streams, filename string, classes bool) int {
+	if _, err := readInput(s, filename); err != nil {
+		return fail(s, "-html", err)
+	}
+	if err := canvas.ExportHTML(s.out, termdraw.HTMLOptions{Classes: classes}); err != nil {
+		return fail(s, "-html", err)
+	}
+	return exitOK
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/png"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const fixture = "testdata/box.tdraw"
+
+// runTest runs the command line args with stdin, and returns the exit code
+// and what was written to stdout.
+func runTest(t *testing.T, stdin string, args ...string) (int, string) {
+	t.Helper()
+	var out, errs bytes.Buffer
+	code, edit, _ := runCLI(streams{strings.NewReader(stdin), &out, &errs}, args)
+	if edit {
+		t.Fatalf("%q starts the editor", args)
+	}
+	if code != exitOK && errs.Len() == 0 {
+		t.Errorf("%q: exit code %d without an error message", args, code)
+	}
+	return code, out.String()
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		format string
+		check  func(out string) bool
+	}{
+		{"text", func(out string) bool { return out == "╭──╮\n│hi│\n╰──╯" }},
+		{"ansi", func(out string) bool {
+			return strings.Contains(out, "│\x1b[1;38;2;170;0;0;48;2;0;0;0mhi\x1b[0m│\n")
+		}},
+		{"html", func(out string) bool {
+			return strings.HasPrefix(out, "<!DOCTYPE html>") && strings.Contains(out, "font-weight:bold\">hi</span>")
+		}},
+		{"svg", func(out string) bool {
+			var svg struct {
+				Width int `xml:"width,attr"`
+			}
+			return xml.Unmarshal([]byte(out), &svg) == nil && svg.Width == 40
+		}},
+		{"png", func(out string) bool {
+			img, err := png.Decode(strings.NewReader(out))
+			return err == nil && img.Bounds().Dx() == 32 && img.Bounds().Dy() == 48
+		}},
+	}
+	for _, tc := range tests {
+		code, out := runTest(t, "", "convert", "-to", tc.format, fixture)
+		if code != exitOK || !tc.check(out) {
+			t.Errorf("%s: exit code %d, output %q", tc.format, code, out)
+		}
+	}
+}
+
+func TestConvertToFile(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "box.html")
+	// Flags may follow the input file
+	if code, _ := runTest(t, "", "convert", fixture, "-o", out); code != exitOK {
+		t.Fatalf("exit code %d", code)
+	}
+	data, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "<!DOCTYPE html>") {
+		t.Errorf("output is %q, want an HTML page", data)
+	}
+}
+
+func TestStdio(t *testing.T) {
+	if code, out := runTest(t, "a  \r\nb\n\n", "normalize"); code != exitOK || out != "a\nb" {
+		t.Errorf("normalize: exit code %d, output %q", code, out)
+	}
+	if code, out := runTest(t, "\x1b[31mred", "render", "-colors", "16"); code != exitOK || out != "\x1b[31;40mred\x1b[0m\n" {
+		t.Errorf("render: exit code %d, output %q", code, out)
+	}
+	if code, out := runTest(t, "", "-html", "-html-classes", fixture); code != exitOK || !strings.Contains(out, `<span class="td-fg-4 td-bg-0 td-bold">hi</span>`) {
+		t.Errorf("-html: exit code %d, output %q", code, out)
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"convert", "-h"}, exitOK},
+		{[]string{"convert", "-to", "nope", fixture}, exitUsage},
+		{[]string{"convert", fixture}, exitUsage},
+		{[]string{"convert", "-o", "box.unknown", fixture}, exitUsage},
+		{[]string{"convert", "-to", "html", fixture, fixture}, exitUsage},
+		{[]string{"convert", "-bogus", fixture}, exitUsage},
+		{[]string{"convert", "-to", "html", missing}, exitError},
+		{[]string{"convert", "-to", "png", "-o", filepath.Join(missing, "box.png"), fixture}, exitError},
+		{[]string{"render", "-colors", "8", fixture}, exitUsage},
+		{[]string{"render", missing}, exitError},
+		{[]string{"normalize", "-w"}, exitUsage},
+		{[]string{"normalize", missing}, exitError},
+		{[]string{"-html"}, exitUsage},
+		{[]string{"-html", missing}, exitError},
+		{[]string{"-bogus"}, exitUsage},
+	}
+	for _, tc := range tests {
+		if got, _ := runTest(t, "", tc.args...); got != tc.want {
+			t.Errorf("%q: exit code %d, want %d", tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestEditorArgs(t *testing.T) {
+	var out, errs bytes.Buffer
+	s := streams{strings.NewReader(""), &out, &errs}
+	if code, edit, filename := runCLI(s, []string{"drawing.txt"}); code != exitOK || !edit || filename != "drawing.txt" {
+		t.Errorf("got %d, %v, %q, want the editor for drawing.txt", code, edit, filename)
+	}
+	if _, edit, filename := runCLI(s, nil); !edit || filename != "" {
+		t.Errorf("got %v, %q, want the editor without a file", edit, filename)
+	}
+}
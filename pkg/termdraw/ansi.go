@@ -0,0 +1,174 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/asig/termbox-go"
+)
+
+// ColorMode defines which colors are used for ANSI escape sequences.
+type ColorMode uint8
+
+const (
+	ColorModeTrue ColorMode = iota
+	ColorMode256
+	ColorMode16
+)
+
+var (
+	// ANSI color numbers of the VGA palette entries
+	vgaToANSI = []int{0, 4, 2, 6, 1, 5, 3, 7, 8, 12, 10, 14, 9, 13, 11, 15}
+
+	// Intensity levels of the 6x6x6 color cube of the 256 color palette
+	cubeLevels = []int{0, 95, 135, 175, 215, 255}
+
+	// SGR parameters of the text attributes
+	attrSGR = []struct {
+		attr termbox.Attribute
+		sgr  int
+	}{
+		{termbox.AttrBold, 1},
+		{termbox.AttrDim, 2},
+		{termbox.AttrCursive, 3},
+		{termbox.AttrUnderline, 4},
+		{termbox.AttrBlink, 5},
+		{termbox.AttrReverse, 7},
+		{termbox.AttrHidden, 8},
+	}
+)
+
+// sgrState is what the terminal has been told so far. Empty colors are the
+// terminal's default colors.
+type sgrState struct {
+	fg, bg string
+	attrs  termbox.Attribute
+}
+
+// nearest256 returns the entry of the 256 color palette closest to col.
+// The first 16 entries are left out, as they depend on the terminal's
+// settings.
+func nearest256(col termbox.Attribute) int {
+	best, bestDist := 0, -1
+	for i := 16; i < 256; i++ {
+		var r, g, b int
+		if i < 232 {
+			r, g, b = cubeLevels[(i-16)/36], cubeLevels[(i-16)/6%6], cubeLevels[(i-16)%6]
+		} else {
+			r = 8 + 10*(i-232)
+			g, b = r, r
+		}
+		if d := colorDistance(col, termbox.RGBToAttribute(uint8(r), uint8(g), uint8(b))); bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// sgrColor returns the SGR parameters selecting col. base is 30 for the
+// foreground and 40 for the background.
+func sgrColor(col termbox.Attribute, mode ColorMode, base int) string {
+	switch mode {
+	case ColorMode256:
+		return fmt.Sprintf("%d;5;%d", base+8, nearest256(col))
+	case ColorMode16:
+		n := vgaToANSI[nearestPaletteIndex(col)]
+		if n >= 8 {
+			// Bright colors
+			return strconv.Itoa(base + 60 + n - 8)
+		}
+		return strconv.Itoa(base + n)
+	default:
+		r, g, b := termbox.AttributeToRGB(col)
+		return fmt.Sprintf("%d;2;%d;%d;%d", base+8, r, g, b)
+	}
+}
+
+// sgrState returns what the terminal needs to be told to show cl. Cells
+// with the canvas' default colors use the terminal's default colors.
+func (c *Canvas) sgrState(cl cell, mode ColorMode) sgrState {
+	fg, fgAttrs := splitAttribute(cl.fg)
+	bg, bgAttrs := splitAttribute(cl.bg)
+	s := sgrState{attrs: fgAttrs | bgAttrs}
+	if cl.fg != c.fg || cl.bg != c.bg {
+		s.fg = sgrColor(fg, mode, 30)
+		s.bg = sgrColor(bg, mode, 40)
+	}
+	return s
+}
+
+// sgrTransition returns the escape sequence switching the terminal from
+// state cur to state next, or "" if nothing needs to change.
+func sgrTransition(cur, next sgrState) string {
+	var params []string
+	if cur.attrs&^next.attrs != 0 {
+		// Attributes can't be switched off individually in a portable way
+		params = append(params, "0")
+		cur = sgrState{}
+	}
+	for _, as := range attrSGR {
+		if next.attrs&as.attr != 0 && cur.attrs&as.attr == 0 {
+			params = append(params, strconv.Itoa(as.sgr))
+		}
+	}
+	if next.fg != cur.fg {
+		if next.fg == "" {
+			params = append(params, "39")
+		} else {
+			params = append(params, next.fg)
+		}
+	}
+	if next.bg != cur.bg {
+		if next.bg == "" {
+			params = append(params, "49")
+		} else {
+			params = append(params, next.bg)
+		}
+	}
+	if len(params) == 0 {
+		return ""
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+// ExportANSI writes the canvas as text with ANSI escape sequences for the
+// colors, ready to be shown with e.g. cat.
+func (c *Canvas) ExportANSI(w io.Writer, mode ColorMode) error {
+	bw := bufio.NewWriter(w)
+	for _, row := range c.trimmedRows() {
+		var cur sgrState
+		for _, cl := range row {
+			next := c.sgrState(cl, mode)
+			bw.WriteString(sgrTransition(cur, next))
+			bw.WriteRune(cl.ch)
+			cur = next
+		}
+		// Don't let colors bleed into the next line
+		if cur != (sgrState{}) {
+			bw.WriteString("\x1b[0m")
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/asig/termbox-go"
+)
+
+func ansiCanvas() *Canvas {
+	c := NewCanvas(0, 0, 20, 5)
+	c.SetText([]string{"plain", "", "", "text"})
+	c.SetColors(ColRed, ColBlack)
+	c.SetRune(Pos{0, 1}, 'r')
+	c.SetRune(Pos{1, 1}, 'r')
+	c.SetColors(ColRed|termbox.AttrBold, ColBlack)
+	c.SetRune(Pos{2, 1}, 'b')
+	c.SetColors(ColLightGrey, ColBlack)
+	c.SetRune(Pos{3, 1}, 'n')
+	c.SetColors(ColGreen, ColBlack)
+	c.SetRune(Pos{0, 2}, 'g')
+	return c
+}
+
+func TestExportANSI(t *testing.T) {
+	tests := []struct {
+		mode ColorMode
+		want []string
+	}{
+		{ColorModeTrue, []string{
+			"plain",
+			"\x1b[38;2;170;0;0;48;2;0;0;0mrr\x1b[1mb\x1b[0mn",
+			"\x1b[38;2;0;170;0;48;2;0;0;0mg\x1b[0m",
+			"text",
+		}},
+		{ColorMode256, []string{
+			"plain",
+			"\x1b[38;5;124;48;5;16mrr\x1b[1mb\x1b[0mn",
+			"\x1b[38;5;34;48;5;16mg\x1b[0m",
+			"text",
+		}},
+		{ColorMode16, []string{
+			"plain",
+			"\x1b[31;40mrr\x1b[1mb\x1b[0mn",
+			"\x1b[32;40mg\x1b[0m",
+			"text",
+		}},
+	}
+	c := ansiCanvas()
+	for _, tc := range tests {
+		var buf bytes.Buffer
+		if err := c.ExportANSI(&buf, tc.mode); err != nil {
+			t.Fatal(err)
+		}
+		want := strings.Join(tc.want, "\n") + "\n"
+		if got := buf.String(); got != want {
+			t.Errorf("mode %d: got %q, want %q", tc.mode, got, want)
+		}
+	}
+}
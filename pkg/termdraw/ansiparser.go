@@ -0,0 +1,342 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/asig/termbox-go"
+)
+
+const (
+	ansiTabWidth = 8
+
+	// Classic ANSI art is drawn for 80 column screens
+	ansiArtWidth = 80
+
+	// Largest parameter of a control sequence, and the furthest the cursor
+	// can be moved by one
+	maxCSIParam = 9999
+)
+
+// ansiColor is a color as set by SGR sequences. The basic 8 colors are kept
+// as index, because they get brighter with bold text.
+type ansiColor struct {
+	isDefault bool
+	index     int // -1 if not one of the basic 8 colors
+	col       termbox.Attribute
+}
+
+type ansiParser struct {
+	c *Canvas
+
+	rows   [][]cell
+	x, y   int
+	savedX int
+	savedY int
+	wrap   int
+	fg, bg ansiColor
+	attrs  termbox.Attribute
+}
+
+// IsANSI checks whether data contains ANSI escape sequences.
+func IsANSI(data []byte) bool {
+	return bytes.Contains(data, []byte("\x1b["))
+}
+
+// ImportANSI replaces the canvas' content with text containing ANSI escape
+// sequences. Colors (16, 256 and 24-bit), text attributes and cursor
+// movement are interpreted, everything else is ignored. Data that is not
+// valid UTF-8 is read as code page 437. If art is set, the data is treated
+// as classic ANSI art: it is always read as code page 437, and lines wrap
+// after 80 columns.
+func (c *Canvas) ImportANSI(data []byte, art bool) {
+	p := &ansiParser{
+		c:  c,
+		fg: ansiColor{isDefault: true, index: -1},
+		bg: ansiColor{isDefault: true, index: -1},
+	}
+	if art {
+		p.wrap = ansiArtWidth
+	}
+
+	var text []rune
+	if !art && utf8.Valid(data) {
+		text = []rune(string(data))
+	} else {
+		text = make([]rune, len(data))
+		for i, b := range data {
+			text[i] = cp437Rune(b)
+		}
+	}
+	p.parse(text)
+
+	c.setRows(p.rows)
+}
+
+func (p *ansiParser) parse(text []rune) {
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+		switch {
+		case ch == 0x1a:
+			// End of file, followed by SAUCE metadata
+			return
+		case ch == 0x1b:
+			i = p.escape(text, i+1)
+		case ch == '\r':
+			p.x = 0
+		case ch == '\n':
+			p.x = 0
+			p.y++
+		case ch == '\t':
+			p.x = (p.x/ansiTabWidth + 1) * ansiTabWidth
+		case ch < ' ' || ch == 0x7f:
+			// Other control characters
+		default:
+			p.put(ch)
+		}
+	}
+}
+
+func (p *ansiParser) put(ch rune) {
+	if p.wrap > 0 && p.x >= p.wrap {
+		p.x = 0
+		p.y++
+	}
+	for len(p.rows) <= p.y {
+		p.rows = append(p.rows, nil)
+	}
+	for len(p.rows[p.y]) <= p.x {
+		p.rows[p.y] = append(p.rows[p.y], p.c.blank())
+	}
+	p.rows[p.y][p.x] = cell{
+		ch:   ch,
+		tile: TileFromRune(ch),
+		fg:   p.color(p.fg, p.c.fg, true) | p.attrs,
+		bg:   p.color(p.bg, p.c.bg, false),
+	}
+	p.x++
+}
+
+// color resolves an SGR color.
+func (p *ansiParser) color(ac ansiColor, def termbox.Attribute, isFg bool) termbox.Attribute {
+	switch {
+	case ac.isDefault:
+		return def
+	case ac.index >= 0 && isFg && p.attrs&termbox.AttrBold != 0:
+		// Bold text uses the bright variants of the basic colors
+		return ansi256Color(ac.index + 8)
+	case ac.index >= 0:
+		return ansi256Color(ac.index)
+	}
+	return ac.col
+}
+
+// ansi256Color returns the color of an entry of the 256 color palette.
+func ansi256Color(n int) termbox.Attribute {
+	switch {
+	case n < 16:
+		for i, a := range vgaToANSI {
+			if a == n {
+				return Palette[i]
+			}
+		}
+	case n < 232:
+		n -= 16
+		return termbox.RGBToAttribute(uint8(cubeLevels[n/36]), uint8(cubeLevels[n/6%6]), uint8(cubeLevels[n%6]))
+	case n < 256:
+		l := uint8(8 + 10*(n-232))
+		return termbox.RGBToAttribute(l, l, l)
+	}
+	return ColBlack
+}
+
+// escape handles the escape sequence starting at text[i], right after the
+// ESC. It returns the index of the sequence's last rune.
+func (p *ansiParser) escape(text []rune, i int) int {
+	if i >= len(text) {
+		return i
+	}
+	switch text[i] {
+	case '[':
+		// Control Sequence: parameters, intermediate bytes, final byte
+		start := i + 1
+		for i = start; i < len(text); i++ {
+			if text[i] >= 0x40 && text[i] <= 0x7e {
+				p.csi(string(text[start:i]), text[i])
+				return i
+			}
+		}
+		return i
+	case ']':
+		// Operating System Command, terminated by BEL or ST
+		for i++; i < len(text); i++ {
+			if text[i] == 0x07 {
+				return i
+			}
+			if text[i] == 0x1b && i+1 < len(text) && text[i+1] == '\\' {
+				return i + 1
+			}
+		}
+		return i
+	}
+	// Some other two character sequence
+	return i
+}
+
+// csiParams returns the numeric parameters of a control sequence. Missing,
+// negative and invalid parameters are returned as 0, and large ones are
+// capped at maxCSIParam.
+func csiParams(s string) []int {
+	s = strings.TrimLeft(s, "?<=>")
+	if s == "" {
+		return nil
+	}
+	var params []int
+	for _, part := range strings.Split(s, ";") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			n = 0
+		}
+		params = append(params, min(n, maxCSIParam))
+	}
+	return params
+}
+
+// param returns parameter i, or def if it is missing or 0.
+func param(params []int, i, def int) int {
+	if i >= len(params) || params[i] == 0 {
+		return def
+	}
+	return params[i]
+}
+
+func (p *ansiParser) csi(s string, final rune) {
+	params := csiParams(s)
+	switch final {
+	case 'm':
+		p.sgr(params)
+	case 'H', 'f':
+		// Cursor Position
+		p.y = param(params, 0, 1) - 1
+		p.x = param(params, 1, 1) - 1
+	case 'A':
+		p.y = max(0, p.y-param(params, 0, 1))
+	case 'B':
+		p.y = advance(p.y, param(params, 0, 1))
+	case 'C':
+		p.x = advance(p.x, param(params, 0, 1))
+	case 'D':
+		p.x = max(0, p.x-param(params, 0, 1))
+	case 'G':
+		// Cursor Horizontal Absolute
+		p.x = param(params, 0, 1) - 1
+	case 'J':
+		// Erase in Display: only "clear screen" is interesting
+		if param(params, 0, 0) == 2 {
+			p.rows = nil
+			p.x, p.y = 0, 0
+		}
+	case 'K':
+		// Erase in Line: only "to the end of the line" is interesting
+		if param(params, 0, 0) == 0 && p.y < len(p.rows) && p.x < len(p.rows[p.y]) {
+			p.rows[p.y] = p.rows[p.y][:p.x]
+		}
+	case 's':
+		p.savedX, p.savedY = p.x, p.y
+	case 'u':
+		p.x, p.y = p.savedX, p.savedY
+	}
+	p.x, p.y = max(p.x, 0), max(p.y, 0)
+}
+
+// advance returns pos moved forward by n, but not beyond maxCSIParam unless
+// it already is.
+func advance(pos, n int) int {
+	return max(pos, min(pos+n, maxCSIParam))
+}
+
+// Select Graphic Rendition
+func (p *ansiParser) sgr(params []int) {
+	if len(params) == 0 {
+		params = []int{0}
+	}
+	for i := 0; i < len(params); i++ {
+		n := params[i]
+		switch {
+		case n == 0:
+			p.fg = ansiColor{isDefault: true, index: -1}
+			p.bg = ansiColor{isDefault: true, index: -1}
+			p.attrs = 0
+		case n == 38 || n == 48:
+			var col termbox.Attribute
+			col, i = extendedColor(params, i+1)
+			ac := ansiColor{index: -1, col: col}
+			if n == 38 {
+				p.fg = ac
+			} else {
+				p.bg = ac
+			}
+		case n == 39:
+			p.fg = ansiColor{isDefault: true, index: -1}
+		case n == 49:
+			p.bg = ansiColor{isDefault: true, index: -1}
+		case n >= 30 && n <= 37:
+			p.fg = ansiColor{index: n - 30}
+		case n >= 40 && n <= 47:
+			p.bg = ansiColor{index: n - 40}
+		case n >= 90 && n <= 97:
+			p.fg = ansiColor{index: -1, col: ansi256Color(n - 90 + 8)}
+		case n >= 100 && n <= 107:
+			p.bg = ansiColor{index: -1, col: ansi256Color(n - 100 + 8)}
+		case n == 22:
+			p.attrs &^= termbox.AttrBold | termbox.AttrDim
+		case n >= 23 && n <= 28:
+			// Switch off the attribute that n-20 switches on
+			for _, as := range attrSGR {
+				if as.sgr == n-20 {
+					p.attrs &^= as.attr
+				}
+			}
+		default:
+			for _, as := range attrSGR {
+				if as.sgr == n {
+					p.attrs |= as.attr
+				}
+			}
+		}
+	}
+}
+
+// extendedColor parses the parameters of a 256 color or 24-bit color
+// starting at params[i]. It returns the color and the index of the last
+// parameter used.
+func extendedColor(params []int, i int) (termbox.Attribute, int) {
+	switch {
+	case i+1 < len(params) && params[i] == 5:
+		return ansi256Color(params[i+1]), i + 1
+	case i+3 < len(params) && params[i] == 2:
+		r, g, b := min(params[i+1], 255), min(params[i+2], 255), min(params[i+3], 255)
+		return termbox.RGBToAttribute(uint8(r), uint8(g), uint8(b)), i + 3
+	}
+	return ColBlack, len(params)
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/asig/termbox-go"
+)
+
+func importANSI(s string, art bool) *Canvas {
+	c := NewCanvas(0, 0, 80, 25)
+	c.ImportANSI([]byte(s), art)
+	return c
+}
+
+func TestImportANSI(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"cursor movement", "abc\x1b[2Dx\x1b[2By\x1b[Az\x1b[1;6Hq\x1b[s\x1b[3;1Hw\x1b[uv", []string{"axc  qv", "   z", "w y"}},
+		{"line endings and tabs", "a\r\nb\tc\rd", []string{"a", "d       c"}},
+		{"clear screen", "old\x1b[2Jnew", []string{"new"}},
+		{"erase to end of line", "abcdef\x1b[3D\x1b[Kx", []string{"abcx"}},
+		{"SAUCE record", "art\x1aSAUCE00 title", []string{"art"}},
+		{"other sequences", "\x1b]0;title\x07a\x1b[?25lb\x1b(Bc", []string{"abBc"}},
+
+		// Negative and invalid parameters count as 0
+		{"negative row", "\x1b[-5;1Hx", []string{"x"}},
+		{"negative column", "\x1b[1;-3Hx", []string{"x"}},
+		{"negative forward", "ab\x1b[-9Cx", []string{"ab x"}},
+		{"negative back", "ab\x1b[-9Dx", []string{"ax"}},
+		{"negative down", "a\x1b[-9Bx", []string{"a", " x"}},
+		{"negative column absolute", "ab\x1b[-4Gx", []string{"xb"}},
+		{"negative erase", "abc\x1b[-2Kx", []string{"abcx"}},
+		{"invalid parameter", "\x1b[3;:Hy", []string{"", "", "y"}},
+	}
+	for _, tc := range tests {
+		if got := trimmedText(importANSI(tc.in, false)); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestImportANSILargeParameters(t *testing.T) {
+	for _, in := range []string{
+		"\x1b[999999999Cx",
+		"\x1b[1;999999999Hx",
+		"\x1b[999999999Gx",
+		strings.Repeat("\x1b[9999C", 100) + "x",
+	} {
+		c := importANSI(in, false)
+		if w, h := c.Extent(); w > maxCSIParam+1 || h != 1 {
+			t.Errorf("%q: extent is %dx%d, want at most %dx1", in, w, h, maxCSIParam+1)
+		}
+	}
+	c := importANSI(strings.Repeat("\x1b[9999B", 100)+"x", false)
+	if _, h := c.Extent(); h > maxCSIParam+1 {
+		t.Errorf("height is %d, want at most %d", h, maxCSIParam+1)
+	}
+}
+
+func TestImportANSIColors(t *testing.T) {
+	c := importANSI("\x1b[31mr\x1b[1mR\x1b[0;44mb\x1b[38;5;196mp\x1b[38;2;1;2;3;48;2;4;5;6mt\x1b[39;49md\x1b[4;97mu", false)
+	tests := []struct {
+		x      int
+		fg, bg termbox.Attribute
+	}{
+		{0, ColRed, c.bg},
+		// Bold makes the basic colors bright
+		{1, ansi256Color(9) | termbox.AttrBold, c.bg},
+		{2, c.fg, ColBlue},
+		{3, termbox.RGBToAttribute(255, 0, 0), ColBlue},
+		{4, termbox.RGBToAttribute(1, 2, 3), termbox.RGBToAttribute(4, 5, 6)},
+		{5, c.fg, c.bg},
+		{6, ColWhite | termbox.AttrUnderline, c.bg},
+	}
+	for _, tc := range tests {
+		cl := c.cellAt(Pos{tc.x, 0})
+		if cl.fg != tc.fg || cl.bg != tc.bg {
+			t.Errorf("cell %d (%c) is %v/%v, want %v/%v", tc.x, cl.ch, cl.fg, cl.bg, tc.fg, tc.bg)
+		}
+	}
+}
+
+func TestImportANSIArt(t *testing.T) {
+	c := importANSI("\xc9\xcd\xbb\r\n"+strings.Repeat("a", ansiArtWidth+1), true)
+	want := []string{"╔═╗", strings.Repeat("a", ansiArtWidth), "a"}
+	if got := trimmedText(c); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := c.Tile(Pos{0, 0}); got != TileFromRune('╔') {
+		t.Errorf("tile at 0,0 is %q", got.pattern())
+	}
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+// Tiles of the characters ASCII boxes are made of
+var asciiTiles = map[rune]Tile{
+	'-': newTile("a a "),
+	'|': newTile(" a a"),
+	'+': newTile("aaaa"),
+}
+
+// asciiChar returns the character at p if it is an ASCII border character
+// without a tile, and 0 otherwise.
+func (c *Canvas) asciiChar(p Pos) rune {
+	if p.X < 0 || p.Y < 0 {
+		return 0
+	}
+	cl := c.cellAt(p)
+	if _, ok := asciiTiles[cl.ch]; !ok || cl.tile != 0 {
+		return 0
+	}
+	return cl.ch
+}
+
+// isASCIICorner tells whether p holds a '+' that joins a horizontal and a
+// vertical line.
+func (c *Canvas) isASCIICorner(p Pos) bool {
+	return c.asciiChar(p) == '+' &&
+		(c.asciiChar(p.Step(DirLeft)) == '-' || c.asciiChar(p.Step(DirRight)) == '-') &&
+		(c.asciiChar(p.Step(DirUp)) == '|' || c.asciiChar(p.Step(DirDown)) == '|')
+}
+
+// asciiJoined tells whether the ASCII border characters at p and its
+// neighbour in direction d are part of a box edge: a run of '-' or '|' that
+// ends in a corner. Corners are never joined directly, so that "C++" or
+// "a+-b" stay text.
+func (c *Canvas) asciiJoined(p Pos, d Direction) bool {
+	n := p.Step(d)
+	a, b := c.asciiChar(p), c.asciiChar(n)
+	if a == 0 || b == 0 || a == '+' && b == '+' ||
+		asciiTiles[a].Dir(d) == BorderStyle_None || asciiTiles[b].Dir(d.Inverse()) == BorderStyle_None {
+		return false
+	}
+	s, line := p, a
+	if a == '+' {
+		s, line = n, b
+	}
+	for _, e := range []Direction{d, d.Inverse()} {
+		q := s
+		for c.asciiChar(q) == line {
+			q = q.Step(e)
+		}
+		if c.isASCIICorner(q) {
+			return true
+		}
+	}
+	return false
+}
+
+// asciiTile returns the tile of the ASCII border character at p, with arms
+// to the neighbours it is joined with.
+func (c *Canvas) asciiTile(p Pos) Tile {
+	var t Tile
+	for _, d := range directions {
+		if c.asciiJoined(p, d) {
+			t = t.WithDir(d, BorderStyle_ASCII)
+		}
+	}
+	return t
+}
+
+// recognizeASCII gives ASCII tiles to the boxes in r that were typed or
+// loaded as plain '+', '-' and '|' characters. Dashes, bars and pluses in
+// ordinary text are left alone. Lines leaving r keep their arms, but the
+// cells outside aren't changed.
+func (c *Canvas) recognizeASCII(r Rect) {
+	tiles := make(map[Pos]Tile)
+	for y := r.Y; y < r.Y+r.H; y++ {
+		for x := r.X; x < r.X+r.W; x++ {
+			if t := c.asciiTile(Pos{x, y}); t != 0 {
+				tiles[Pos{x, y}] = t
+			}
+		}
+	}
+	for y := r.Y; y < r.Y+r.H; y++ {
+		for x := r.X; x < r.X+r.W; x++ {
+			if t, ok := tiles[Pos{x, y}]; ok {
+				c.replaceTile(Pos{x, y}, t)
+			}
+		}
+	}
+}
+
+// ConvertToASCII replaces all borders in r by ASCII ones.
+func (c *Canvas) ConvertToASCII(r Rect) {
+	c.BeginGroup()
+	defer c.EndGroup()
+
+	c.restyle(r, BorderStyle_None, BorderStyle_ASCII)
+}
+
+// ConvertFromASCII replaces the ASCII borders in r by ones in style bs,
+// including ASCII boxes that were typed or loaded from a text file.
+func (c *Canvas) ConvertFromASCII(r Rect, bs BorderStyle) {
+	c.BeginGroup()
+	defer c.EndGroup()
+
+	c.recognizeASCII(r)
+	c.restyle(r, BorderStyle_ASCII, bs)
+}
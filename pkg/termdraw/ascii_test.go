@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConvertToASCII(t *testing.T) {
+	c := NewCanvas(0, 0, 20, 10)
+	c.SetText([]string{
+		"╔═══╦═══╗",
+		"║ a ║ b ║",
+		"╠═══╬═══╣",
+		"╚═══╩═══╝  ╲",
+	})
+	w, h := c.Extent()
+	c.ConvertToASCII(Rect{0, 0, w, h})
+	want := []string{
+		"+---+---+",
+		"| a | b |",
+		"+---+---+",
+		"+---+---+  \\",
+	}
+	if got := c.AsText(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// ASCII borders merge like all others
+	c.DrawBox(Rect{4, 1, 3, 3}, BorderStyle_Light)
+	if got := c.cellAt(Pos{4, 3}).ch; got != '+' {
+		t.Errorf("crossing is %c, want +", got)
+	}
+	c.Undo()
+	c.Undo()
+	if got := c.cellAt(Pos{0, 0}).ch; got != '╔' {
+		t.Errorf("after undo, corner is %c, want ╔", got)
+	}
+}
+
+func TestConvertFromASCII(t *testing.T) {
+	text := []string{
+		"+---+---+",
+		"| a | b |  a-b, 1+1 |",
+		"+---+---+",
+		"",
+		"-----",
+		"Use C++ or i++ here, a+-b",
+		"++--+ +-",
+	}
+	c := NewCanvas(0, 0, 30, 10)
+	c.SetText(text)
+	w, h := c.Extent()
+	c.ConvertFromASCII(Rect{0, 0, w, h}, BorderStyle_Rounded)
+	want := []string{
+		"╭───┬───╮",
+		"│ a │ b │  a-b, 1+1 |",
+		"╰───┴───╯",
+		"",
+		"-----",
+		"Use C++ or i++ here, a+-b",
+		"++--+ +-",
+	}
+	if got := c.AsText(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Only the selection is converted
+	c.SetText(text)
+	c.ConvertFromASCII(Rect{0, 0, 5, 3}, BorderStyle_Double)
+	if got := c.AsText()[0]; got != "╔═══╦---+" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestStrokeReloadedASCII(t *testing.T) {
+	c := NewCanvas(0, 0, 20, 10)
+	c.DrawBox(Rect{0, 0, 6, 3}, BorderStyle_ASCII)
+
+	// Saved as text and loaded again, the box has no tiles
+	text := trimmedText(c)
+	c = NewCanvas(0, 0, 20, 10)
+	c.SetText(text)
+	c.strokeN(Pos{2, 1}, DirDown, 2, BorderStyle_ASCII)
+	c.strokeN(Pos{3, 1}, DirRight, 4, BorderStyle_ASCII)
+	want := []string{
+		"+----+",
+		"| |--+--",
+		"+-+--+",
+		"  |",
+	}
+	if got := trimmedText(c); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
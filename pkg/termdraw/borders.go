@@ -29,8 +29,15 @@ const (
 	BorderStyle_Rounded
 	BorderStyle_Heavy
 	BorderStyle_Double
+	BorderStyle_LightDoubleDash
+	BorderStyle_HeavyDoubleDash
+	BorderStyle_LightTripleDash
+	BorderStyle_HeavyTripleDash
+	BorderStyle_LightQuadrupleDash
+	BorderStyle_HeavyQuadrupleDash
+	BorderStyle_ASCII
 
-	BorderStyle_Max = BorderStyle_Double
+	BorderStyle_Max = BorderStyle_ASCII
 )
 
 var (
@@ -63,6 +70,55 @@ var (
 			{'║', ' ', '║', ' ', '║'},
 			{'╚', '═', '╩', '═', '╝'},
 		},
+		BorderStyle_LightDoubleDash: {
+			{'┌', '╌', '┬', '╌', '┐'},
+			{'╎', ' ', '╎', ' ', '╎'},
+			{'├', '╌', '┼', '╌', '┤'},
+			{'╎', ' ', '╎', ' ', '╎'},
+			{'└', '╌', '┴', '╌', '┘'},
+		},
+		BorderStyle_HeavyDoubleDash: {
+			{'┏', '╍', '┳', '╍', '┓'},
+			{'╏', ' ', '╏', ' ', '╏'},
+			{'┣', '╍', '╋', '╍', '┫'},
+			{'╏', ' ', '╏', ' ', '╏'},
+			{'┗', '╍', '┻', '╍', '┛'},
+		},
+		BorderStyle_LightTripleDash: {
+			{'┌', '┄', '┬', '┄', '┐'},
+			{'┆', ' ', '┆', ' ', '┆'},
+			{'├', '┄', '┼', '┄', '┤'},
+			{'┆', ' ', '┆', ' ', '┆'},
+			{'└', '┄', '┴', '┄', '┘'},
+		},
+		BorderStyle_HeavyTripleDash: {
+			{'┏', '┅', '┳', '┅', '┓'},
+			{'┇', ' ', '┇', ' ', '┇'},
+			{'┣', '┅', '╋', '┅', '┫'},
+			{'┇', ' ', '┇', ' ', '┇'},
+			{'┗', '┅', '┻', '┅', '┛'},
+		},
+		BorderStyle_LightQuadrupleDash: {
+			{'┌', '┈', '┬', '┈', '┐'},
+			{'┊', ' ', '┊', ' ', '┊'},
+			{'├', '┈', '┼', '┈', '┤'},
+			{'┊', ' ', '┊', ' ', '┊'},
+			{'└', '┈', '┴', '┈', '┘'},
+		},
+		BorderStyle_HeavyQuadrupleDash: {
+			{'┏', '┉', '┳', '┉', '┓'},
+			{'┋', ' ', '┋', ' ', '┋'},
+			{'┣', '┉', '╋', '┉', '┫'},
+			{'┋', ' ', '┋', ' ', '┋'},
+			{'┗', '┉', '┻', '┉', '┛'},
+		},
+		BorderStyle_ASCII: {
+			{'+', '-', '+', '-', '+'},
+			{'|', ' ', '|', ' ', '|'},
+			{'+', '-', '+', '-', '+'},
+			{'|', ' ', '|', ' ', '|'},
+			{'+', '-', '+', '-', '+'},
+		},
 		BorderStyle_None:
 		{
 			{' ', ' ', ' ', ' ', ' '},
@@ -79,15 +135,22 @@ var (
 		BorderStyle_Rounded: "Rounded",
 		BorderStyle_Heavy:   "Heavy",
 		BorderStyle_Double:  "Double",
+
+		BorderStyle_LightDoubleDash:    "Light Double Dash",
+		BorderStyle_HeavyDoubleDash:    "Heavy Double Dash",
+		BorderStyle_LightTripleDash:    "Light Triple Dash",
+		BorderStyle_HeavyTripleDash:    "Heavy Triple Dash",
+		BorderStyle_LightQuadrupleDash: "Light Quadruple Dash",
+		BorderStyle_HeavyQuadrupleDash: "Heavy Quadruple Dash",
+		BorderStyle_ASCII:              "ASCII",
 	}
 )
 
 func (b BorderStyle) Prev() BorderStyle {
-	prev := b - 1
-	if prev < 0 {
-		prev = BorderStyle_Max
+	if b == 0 {
+		return BorderStyle_Max
 	}
-	return prev
+	return b - 1
 }
 
 func (b BorderStyle) Next() BorderStyle {
@@ -105,3 +168,30 @@ func (b BorderStyle) Runes() Border {
 func (b BorderStyle) String() string {
 	return borderNames[b]
 }
+
+// Dashes returns the number of dashes per cell of a dashed style, and 0
+// for solid ones.
+func (b BorderStyle) Dashes() int {
+	switch b {
+	case BorderStyle_LightDoubleDash, BorderStyle_HeavyDoubleDash:
+		return 2
+	case BorderStyle_LightTripleDash, BorderStyle_HeavyTripleDash:
+		return 3
+	case BorderStyle_LightQuadrupleDash, BorderStyle_HeavyQuadrupleDash:
+		return 4
+	}
+	return 0
+}
+
+// Solid returns the solid style a dashed style is based on. Dashed lines
+// only have glyphs for straight lines; in corners and junctions they are
+// shown solid.
+func (b BorderStyle) Solid() BorderStyle {
+	switch b {
+	case BorderStyle_LightDoubleDash, BorderStyle_LightTripleDash, BorderStyle_LightQuadrupleDash:
+		return BorderStyle_Light
+	case BorderStyle_HeavyDoubleDash, BorderStyle_HeavyTripleDash, BorderStyle_HeavyQuadrupleDash:
+		return BorderStyle_Heavy
+	}
+	return b
+}
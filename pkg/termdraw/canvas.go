@@ -20,6 +20,7 @@ package termdraw
 
 import (
 	"log"
+	"strings"
 	"unicode/utf8"
 
 	"github.com/asig/termbox-go"
@@ -32,6 +33,10 @@ const (
 	DirDown
 	DirLeft
 	DirRight
+	DirUpLeft
+	DirUpRight
+	DirDownLeft
+	DirDownRight
 )
 
 func (d Direction) Inverse() Direction {
@@ -44,19 +49,73 @@ func (d Direction) Inverse() Direction {
 		return DirRight
 	case DirRight:
 		return DirLeft
+	case DirUpLeft:
+		return DirDownRight
+	case DirUpRight:
+		return DirDownLeft
+	case DirDownLeft:
+		return DirUpRight
+	case DirDownRight:
+		return DirUpLeft
 	}
 	panic("Bad Direction!")
 }
 
-const (
-	canvasWidth  = 2048
-	canvasHeight = 2048
+var (
+	directions         = []Direction{DirUp, DirDown, DirLeft, DirRight}
+	diagonalDirections = []Direction{DirUpLeft, DirUpRight, DirDownRight, DirDownLeft}
+	allDirections      = []Direction{DirUp, DirDown, DirLeft, DirRight, DirUpLeft, DirUpRight, DirDownRight, DirDownLeft}
 )
 
+// IsDiagonal tells whether d is one of the diagonal directions.
+func (d Direction) IsDiagonal() bool {
+	return d >= DirUpLeft
+}
+
+// delta returns the change of coordinates when moving in direction d.
+func (d Direction) delta() (dx, dy int) {
+	switch d {
+	case DirUp:
+		return 0, -1
+	case DirDown:
+		return 0, 1
+	case DirLeft:
+		return -1, 0
+	case DirRight:
+		return 1, 0
+	case DirUpLeft:
+		return -1, -1
+	case DirUpRight:
+		return 1, -1
+	case DirDownLeft:
+		return -1, 1
+	case DirDownRight:
+		return 1, 1
+	}
+	panic("Bad Direction!")
+}
+
+// perpendicular returns the two directions at a right angle to d.
+func (d Direction) perpendicular() (Direction, Direction) {
+	switch d {
+	case DirUp, DirDown:
+		return DirLeft, DirRight
+	case DirLeft, DirRight:
+		return DirUp, DirDown
+	}
+	panic("Bad Direction!")
+}
+
 type Pos struct {
 	X, Y int
 }
 
+// Step returns the position next to p in direction d.
+func (p Pos) Step(d Direction) Pos {
+	dx, dy := d.delta()
+	return Pos{p.X + dx, p.Y + dy}
+}
+
 type cell struct {
 	ch     rune
 	tile   Tile
@@ -64,6 +123,9 @@ type cell struct {
 }
 
 type Canvas struct {
+	// Where the canvas is drawn
+	screen Screen
+
 	// Width and Height of the gadget
 	w, h int
 
@@ -76,73 +138,197 @@ type Canvas struct {
 	// Top-Left coords of the visible canvas
 	ofsX, ofsY int
 
-	cells [][]cell
-	//tiles [][]Tile
-	//cells [][]termbox.Cell
+	// Sparse cell storage: rows and columns are only allocated once
+	// something is written to them. Everything outside is blank.
+	rows [][]cell
 
+	// Colors of empty cells
 	fg, bg termbox.Attribute
+	// Colors used for everything that is drawn or typed
+	penFg, penBg termbox.Attribute
+
+	history history
+
+	// Block selection, spanning from the anchor to the cursor
+	selAnchor Pos
+	selActive bool
+
+	// Block that is being moved with MoveSelection, and the content it covers
+	floating, under *Clipboard
+}
+
+// CanvasOption configures a canvas created by NewCanvas.
+type CanvasOption func(c *Canvas)
+
+// WithScreen makes the canvas draw on s instead of the terminal.
+func WithScreen(s Screen) CanvasOption {
+	return func(c *Canvas) {
+		c.screen = s
+	}
 }
 
-func NewCanvas(x, y int, w, h int) *Canvas {
+func NewCanvas(x, y int, w, h int, opts ...CanvasOption) *Canvas {
 	c := &Canvas{
-		pX:    x,
-		pY:    y,
-		w:     w,
-		h:     h,
-		ofsX:  0,
-		ofsY:  0,
-		fg:    ColLightGrey,
-		bg:    ColBlack,
-		cells: make([][]cell, canvasHeight),
+		screen: TermboxScreen{},
+		pX:     x,
+		pY:     y,
+		w:      w,
+		h:      h,
+		ofsX:   0,
+		ofsY:   0,
+		fg:     ColLightGrey,
+		bg:     ColBlack,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.penFg, c.penBg = c.fg, c.bg
 	c.Clear()
+	c.ResetHistory()
 	return c
 }
 
 func (c *Canvas) Clear() {
-	for i := 0; i < canvasHeight; i++ {
-		c.cells[i] = make([]cell, canvasWidth)
-		for j := 0; j < canvasWidth; j++ {
-			c.cells[i][j] = cell{
-				ch:   ' ',
-				tile: 0,
-				fg:   c.fg,
-				bg:   c.bg,
-			}
+	c.setRows(nil)
+}
+
+func (c *Canvas) blank() cell {
+	return cell{ch: ' ', tile: 0, fg: c.fg, bg: c.bg}
+}
+
+// cellAt returns the cell at p, or a blank cell if nothing is stored there.
+func (c *Canvas) cellAt(p Pos) cell {
+	if p.Y < 0 || p.Y >= len(c.rows) || p.X < 0 || p.X >= len(c.rows[p.Y]) {
+		return c.blank()
+	}
+	return c.rows[p.Y][p.X]
+}
+
+// cellRef returns a pointer to the cell at p, growing the storage as needed.
+func (c *Canvas) cellRef(p Pos) *cell {
+	for len(c.rows) <= p.Y {
+		c.rows = append(c.rows, nil)
+	}
+	row := c.rows[p.Y]
+	for len(row) <= p.X {
+		row = append(row, c.blank())
+	}
+	c.rows[p.Y] = row
+	return &row[p.X]
+}
+
+// setCell replaces the cell at p, recording the change.
+func (c *Canvas) setCell(p Pos, cl cell) {
+	old := c.cellAt(p)
+	if old == cl {
+		return
+	}
+	*c.cellRef(p) = cl
+	c.record(
+		func(c *Canvas) { *c.cellRef(p) = old },
+		func(c *Canvas) { *c.cellRef(p) = cl },
+	)
+}
+
+// setRows replaces the complete content, recording the change.
+func (c *Canvas) setRows(rows [][]cell) {
+	old := c.rows
+	c.rows = rows
+	c.record(
+		func(c *Canvas) { c.rows = old },
+		func(c *Canvas) { c.rows = rows },
+	)
+}
+
+// insertCell inserts cl at p, shifting the rest of the row to the right.
+// p.X must not be beyond the end of the row.
+func (c *Canvas) insertCell(p Pos, cl cell) {
+	row := append(c.rows[p.Y], cell{})
+	copy(row[p.X+1:], row[p.X:])
+	row[p.X] = cl
+	c.rows[p.Y] = row
+}
+
+// deleteCell removes the cell at p, shifting the rest of the row to the left.
+func (c *Canvas) deleteCell(p Pos) {
+	row := c.rows[p.Y]
+	c.rows[p.Y] = append(row[:p.X], row[p.X+1:]...)
+}
+
+// insertRow inserts row at y, shifting the following rows down.
+func (c *Canvas) insertRow(y int, row []cell) {
+	c.rows = append(c.rows, nil)
+	copy(c.rows[y+1:], c.rows[y:])
+	c.rows[y] = row
+}
+
+// deleteRow removes the row at y, shifting the following rows up.
+func (c *Canvas) deleteRow(y int) {
+	c.rows = append(c.rows[:y], c.rows[y+1:]...)
+}
+
+// Extent returns the size of the area that holds content.
+func (c *Canvas) Extent() (w, h int) {
+	return rowsExtent(c.rows)
+}
+
+// rowsExtent returns the width of the longest row and the number of rows.
+func rowsExtent(rows [][]cell) (w, h int) {
+	for _, row := range rows {
+		w = max(w, len(row))
+	}
+	return w, len(rows)
+}
+
+// trimmedRows returns the rows without trailing blank cells and without
+// trailing empty rows.
+func (c *Canvas) trimmedRows() [][]cell {
+	blank := c.blank()
+	rows := make([][]cell, 0, len(c.rows))
+	for _, row := range c.rows {
+		end := len(row)
+		for end > 0 && row[end-1] == blank {
+			end--
 		}
+		rows = append(rows, row[:end])
 	}
+	end := len(rows)
+	for end > 0 && len(rows[end-1]) == 0 {
+		end--
+	}
+	return rows[:end]
 }
 
 func (c *Canvas) AsText() []string {
-	var text []string
-
-	for y := 0; y < canvasHeight; y++ {
-		line := ""
-		for x := 0; x < canvasWidth; x++ {
-			line = line + string(c.cells[y][x].ch)
+	text := make([]string, 0, len(c.rows))
+
+	var sb strings.Builder
+	for _, row := range c.rows {
+		sb.Reset()
+		sb.Grow(len(row))
+		for _, cell := range row {
+			sb.WriteRune(cell.ch)
 		}
-		text = append(text, line)
+		text = append(text, sb.String())
 	}
 	return text
 }
 
 func (c *Canvas) SetText(text []string) {
-	c.Clear()
-	if len(text) > canvasHeight {
-		text = text[:canvasHeight]
-	}
-
+	rows := make([][]cell, len(text))
 	for y, l := range text {
-		for x, i, w := 0, 0, 0; i < len(l); i += w {
-			ch, width := utf8.DecodeRuneInString(l[i:])
-			c.cells[y][x].ch = ch
-			c.cells[y][x].tile = TileFromRune(ch)
-			c.cells[y][x].fg = c.fg
-			c.cells[y][x].bg = c.bg
-			x++
-			w = width
+		row := make([]cell, 0, utf8.RuneCountInString(l))
+		for _, ch := range l {
+			row = append(row, cell{
+				ch:   ch,
+				tile: TileFromRune(ch),
+				fg:   c.fg,
+				bg:   c.bg,
+			})
 		}
+		rows[y] = row
 	}
+	c.setRows(rows)
 }
 
 func (c *Canvas) IncSize(dw, dh int) {
@@ -153,23 +339,51 @@ func (c *Canvas) IncSize(dw, dh int) {
 func (c *Canvas) Draw() {
 	for y := 0; y < c.h; y++ {
 		for x := 0; x < c.w; x++ {
-			cell := c.cells[c.ofsY+y][c.ofsX+x]
-			termbox.SetCell(c.pX+x, c.pY+y, cell.ch, cell.fg, cell.bg)
+			p := Pos{c.ofsX + x, c.ofsY + y}
+			cell := c.cellAt(p)
+			if sel, ok := c.Selection(); ok && sel.Contains(p) {
+				cell.fg, cell.bg = cell.bg, cell.fg
+			}
+			c.screen.SetCell(c.pX+x, c.pY+y, cell.ch, cell.fg, cell.bg)
 		}
 	}
-	termbox.SetCursor(c.pX+(c.cX-c.ofsX), c.pY+(c.cY-c.ofsY))
+	c.screen.SetCursor(c.pX+(c.cX-c.ofsX), c.pY+(c.cY-c.ofsY))
 }
 
-func (c *Canvas) SetTile(p Pos, t Tile) {
-	c.cells[p.Y][p.X].tile = t
-	ch := t.Rune()
-	if ch != ' ' {
-		c.cells[p.Y][p.X].ch = ch
+// SetColors sets the colors used for everything drawn from now on.
+func (c *Canvas) SetColors(fg, bg termbox.Attribute) {
+	c.penFg, c.penBg = fg, bg
+}
+
+func (c *Canvas) Colors() (fg, bg termbox.Attribute) {
+	return c.penFg, c.penBg
+}
+
+// withTile returns cl showing tile t. A cell that loses its last arm
+// becomes blank; otherwise, it keeps its character if t has no glyph.
+func (cl cell) withTile(t Tile) cell {
+	if ch := t.Rune(); ch != ' ' {
+		cl.ch = ch
+	} else if t == 0 && cl.tile != 0 {
+		cl.ch = ' '
 	}
+	cl.tile = t
+	return cl
+}
+
+func (c *Canvas) SetTile(p Pos, t Tile) {
+	cl := c.cellAt(p).withTile(t)
+	cl.fg, cl.bg = c.penFg, c.penBg
+	c.setCell(p, cl)
+}
+
+// replaceTile changes the tile at p, keeping the cell's colors.
+func (c *Canvas) replaceTile(p Pos, t Tile) {
+	c.setCell(p, c.cellAt(p).withTile(t))
 }
 
 func (c *Canvas) Tile(p Pos) Tile {
-	return c.cells[p.Y][p.X].tile
+	return c.cellAt(p).tile
 }
 
 func (c *Canvas) Pos() Pos {
@@ -182,27 +396,22 @@ func (c *Canvas) Pos() Pos {
 
 func (c *Canvas) Move(d Direction) (oldPos, newPos Pos) {
 	oldPos = Pos{X: c.cX, Y: c.cY}
-	switch d {
-	case DirUp:
-		if c.cY > 0 {
-			c.cY--
-		}
-	case DirDown:
-		if c.cY < canvasHeight-1 {
-			c.cY++
-		}
-	case DirLeft:
-		if c.cX > 0 {
-			c.cX--
-		}
-	case DirRight:
-		if c.cX < canvasWidth-1 {
-			c.cX++
+	newPos = oldPos.Step(d)
+	if newPos.X < 0 || newPos.Y < 0 {
+		// Diagonal moves don't slide along the edges
+		if d.IsDiagonal() {
+			return oldPos, oldPos
 		}
+		newPos.X, newPos.Y = max(newPos.X, 0), max(newPos.Y, 0)
 	}
-	newPos = Pos{X: c.cX, Y: c.cY}
+	c.cX, c.cY = newPos.X, newPos.Y
+
+	c.adjustCamera()
 
-	// Adjust the "camera"
+	return oldPos, newPos
+}
+
+func (c *Canvas) adjustCamera() {
 	if c.cX < c.ofsX {
 		c.ofsX = c.cX
 	}
@@ -215,8 +424,6 @@ func (c *Canvas) Move(d Direction) (oldPos, newPos Pos) {
 	if c.cY >= c.ofsY+c.h {
 		c.ofsY = c.cY - c.h + 1
 	}
-
-	return oldPos, newPos
 }
 
 func (c *Canvas) SetPos(p Pos) {
@@ -225,61 +432,75 @@ func (c *Canvas) SetPos(p Pos) {
 	if c.cX < 0 {
 		c.cX = 0
 	}
-	if c.cX >= canvasWidth {
-		c.cX = canvasWidth - 1
-	}
 	if c.cY < 0 {
 		c.cY = 0
 	}
-	if c.cY >= canvasHeight {
-		c.cY = canvasHeight - 1
-	}
+	c.adjustCamera()
 }
 
 func (c *Canvas) Insert(p Pos) {
-	l := len(c.cells[p.Y])
-	for i := l - 2; i >= p.X; i-- {
-		c.cells[p.Y][i+1] = c.cells[p.Y][i]
+	if p.Y >= len(c.rows) || p.X >= len(c.rows[p.Y]) {
+		// Nothing to shift
+		return
 	}
+	cl := c.blank()
+	c.insertCell(p, cl)
+	c.record(
+		func(c *Canvas) { c.deleteCell(p) },
+		func(c *Canvas) { c.insertCell(p, cl) },
+	)
 }
 
 func (c *Canvas) Delete(p Pos) {
-	l := len(c.cells[p.Y])
-	for i := p.X + 1; i < l; i++ {
-		c.cells[p.Y][i-1] = c.cells[p.Y][i]
+	if p.Y >= len(c.rows) || p.X >= len(c.rows[p.Y]) {
+		return
 	}
-	c.cells[p.Y][l-1] = cell{ch: ' ', fg: c.fg, bg: c.bg, tile: 0}
-
+	old := c.rows[p.Y][p.X]
+	c.deleteCell(p)
+	c.record(
+		func(c *Canvas) { c.insertCell(p, old) },
+		func(c *Canvas) { c.deleteCell(p) },
+	)
 }
 
 func (c *Canvas) InsertLine(p Pos) {
-	l := len(c.cells)
-	for i := l - 2; i >= p.Y; i-- {
-		c.cells[i+1] = c.cells[i]
-	}
-	c.cells[p.Y] = make([]cell, canvasWidth)
-	for x := 0; x < len(c.cells[p.Y]); x++ {
-		c.cells[p.Y][x] = cell{ch: ' ', fg: c.fg, bg: c.bg, tile: 0}
+	if p.Y >= len(c.rows) {
+		return
 	}
+	c.insertRow(p.Y, nil)
+	c.record(
+		func(c *Canvas) { c.deleteRow(p.Y) },
+		func(c *Canvas) { c.insertRow(p.Y, nil) },
+	)
 }
 
 func (c *Canvas) DeleteLine(p Pos) {
-	l := len(c.cells)
-	for i := p.Y + 1; i < l; i++ {
-		c.cells[i-1] = c.cells[i]
-	}
-	y := canvasHeight - 1
-	c.cells[y] = make([]cell, canvasWidth)
-	for x := 0; x < len(c.cells[y]); x++ {
-		c.cells[y][x] = cell{ch: ' ', fg: c.fg, bg: c.bg, tile: 0}
+	if p.Y >= len(c.rows) {
+		return
 	}
-
+	old := c.rows[p.Y]
+	c.deleteRow(p.Y)
+	c.record(
+		func(c *Canvas) { c.insertRow(p.Y, old) },
+		func(c *Canvas) { c.deleteRow(p.Y) },
+	)
 }
 
 func (c *Canvas) SetRune(p Pos, ch rune) {
 	if ch == 0 {
 		log.Fatalf("NULL!!!")
 	}
-	c.cells[p.Y][p.X].ch = ch
-	c.cells[p.Y][p.X].tile = 0
+	cl := c.cellAt(p)
+	cl.ch = ch
+	cl.tile = 0
+	cl.fg, cl.bg = c.penFg, c.penBg
+	c.setCell(p, cl)
+}
+
+// Paint changes the colors of the cell at p to the current colors, leaving
+// its content alone.
+func (c *Canvas) Paint(p Pos) {
+	cl := c.cellAt(p)
+	cl.fg, cl.bg = c.penFg, c.penBg
+	c.setCell(p, cl)
 }
@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testDocument returns n lines of a table with borders.
+func testDocument(n int) []string {
+	row := "│ " + strings.Repeat("cell ", 8) + "│ " + strings.Repeat("more text ", 5) + "│"
+	sep := "├" + strings.Repeat("─", 41) + "┼" + strings.Repeat("─", 51) + "┤"
+	lines := make([]string, n)
+	for i := range lines {
+		if i%2 == 0 {
+			lines[i] = row
+		} else {
+			lines[i] = sep
+		}
+	}
+	return lines
+}
+
+// trimmedText returns the content of c without trailing blanks.
+func trimmedText(c *Canvas) []string {
+	var res []string
+	for _, row := range c.trimmedRows() {
+		var sb strings.Builder
+		for _, cl := range row {
+			sb.WriteRune(cl.ch)
+		}
+		res = append(res, sb.String())
+	}
+	return res
+}
+
+func TestTextRoundTrip(t *testing.T) {
+	text := testDocument(100)
+	c := NewCanvas(0, 0, 80, 25)
+	c.SetText(text)
+	if got := c.AsText(); !reflect.DeepEqual(got, text) {
+		t.Errorf("AsText() doesn't return the text set")
+	}
+	if got := c.Tile(Pos{42, 1}); got != newTile("llll") {
+		t.Errorf("tile at 42,1 is %q, want \"llll\"", got.pattern())
+	}
+}
+
+func BenchmarkSetText(b *testing.B) {
+	text := testDocument(10000)
+	c := NewCanvas(0, 0, 80, 25)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.SetText(text)
+	}
+}
+
+func BenchmarkAsText(b *testing.B) {
+	c := NewCanvas(0, 0, 80, 25)
+	c.SetText(testDocument(10000))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.AsText()
+	}
+}
+
+func BenchmarkWriteDocument(b *testing.B) {
+	c := NewCanvas(0, 0, 80, 25)
+	c.SetText(testDocument(10000))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := c.WriteDocument(ioutil.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadDocument(b *testing.B) {
+	c := NewCanvas(0, 0, 80, 25)
+	c.SetText(testDocument(10000))
+	var buf bytes.Buffer
+	if err := c.WriteDocument(&buf); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := c.ReadDocument(bytes.NewReader(buf.Bytes())); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -19,6 +19,8 @@
 package termdraw
 
 import (
+	"fmt"
+
 	"github.com/asig/termbox-go"
 )
 
@@ -40,5 +42,110 @@ var (
 	ColLightMagenta = termbox.RGBToAttribute(255, 85, 255)
 	ColYellow       = termbox.RGBToAttribute(255, 255, 85)
 	ColWhite        = termbox.RGBToAttribute(255, 255, 255)
+
+	// The 16 VGA colors, in VGA order
+	Palette = []termbox.Attribute{
+		ColBlack, ColBlue, ColGreen, ColCyan, ColRed, ColMagenta, ColBrown, ColLightGrey,
+		ColGrey, ColLightBlue, ColLightGreen, ColLightCyan, ColLightRed, ColLightMagenta, ColYellow, ColWhite,
+	}
+
+	paletteNames = []string{
+		"Black", "Blue", "Green", "Cyan", "Red", "Magenta", "Brown", "Light Grey",
+		"Grey", "Light Blue", "Light Green", "Light Cyan", "Light Red", "Light Magenta", "Yellow", "White",
+	}
+
+	// Text attributes that can be combined with a color
+	attrNames = []struct {
+		attr termbox.Attribute
+		name string
+	}{
+		{termbox.AttrBold, "bold"},
+		{termbox.AttrBlink, "blink"},
+		{termbox.AttrHidden, "hidden"},
+		{termbox.AttrDim, "dim"},
+		{termbox.AttrUnderline, "underline"},
+		{termbox.AttrCursive, "cursive"},
+		{termbox.AttrReverse, "reverse"},
+	}
 )
 
+// nearestPaletteIndex returns the index of the palette color closest to col.
+func nearestPaletteIndex(col termbox.Attribute) int {
+	best, bestDist := 0, -1
+	for i, p := range Palette {
+		if d := colorDistance(col, p); bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// colorDistance returns the squared euclidean distance of two colors in RGB
+// space.
+func colorDistance(c1, c2 termbox.Attribute) int {
+	r1, g1, b1 := termbox.AttributeToRGB(c1)
+	r2, g2, b2 := termbox.AttributeToRGB(c2)
+	dr, dg, db := int(r1)-int(r2), int(g1)-int(g2), int(b1)-int(b2)
+	return dr*dr + dg*dg + db*db
+}
+
+// splitAttribute splits an attribute into its color and its text attributes.
+func splitAttribute(a termbox.Attribute) (col, attrs termbox.Attribute) {
+	for _, an := range attrNames {
+		attrs |= a & an.attr
+	}
+	return a &^ attrs, attrs
+}
+
+// resolveColors returns the colors a cell is shown in, taking the reverse
+// and hidden attributes into account, and the cell's text attributes.
+func resolveColors(fgAttr, bgAttr termbox.Attribute) (fg, bg, attrs termbox.Attribute) {
+	fg, fgAttrs := splitAttribute(fgAttr)
+	bg, bgAttrs := splitAttribute(bgAttr)
+	attrs = fgAttrs | bgAttrs
+	if attrs&termbox.AttrReverse != 0 {
+		fg, bg = bg, fg
+	}
+	if attrs&termbox.AttrHidden != 0 {
+		fg = bg
+	}
+	return fg, bg, attrs
+}
+
+// colorHex returns the color part of a in #rrggbb notation.
+func colorHex(a termbox.Attribute) string {
+	r, g, b := termbox.AttributeToRGB(a)
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
+
+// parseColorHex parses a color in #rrggbb notation.
+func parseColorHex(s string) (termbox.Attribute, error) {
+	var r, g, b uint8
+	if len(s) != 7 {
+		return 0, fmt.Errorf("invalid color %q", s)
+	}
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x", &r, &g, &b); err != nil {
+		return 0, fmt.Errorf("invalid color %q", s)
+	}
+	return termbox.RGBToAttribute(r, g, b), nil
+}
+
+// paletteIndex returns the index of col in Palette, or -1 if col is not a
+// palette color.
+func paletteIndex(col termbox.Attribute) int {
+	for i, p := range Palette {
+		if p == col {
+			return i
+		}
+	}
+	return -1
+}
+
+// ColorName returns the name of a palette color, or its hex representation
+// for all other colors.
+func ColorName(col termbox.Attribute) string {
+	if i := paletteIndex(col); i >= 0 {
+		return paletteNames[i]
+	}
+	return colorHex(col)
+}
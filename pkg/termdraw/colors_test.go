@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/asig/termbox-go"
+)
+
+func TestPaint(t *testing.T) {
+	c := NewCanvas(0, 0, 20, 5)
+	c.SetText([]string{"┌─┐ab"})
+	c.SetColors(ColYellow, ColBlue)
+	for x := 0; x < 5; x++ {
+		c.Paint(Pos{x, 0})
+	}
+	for x, want := range []rune("┌─┐ab") {
+		cl := c.cellAt(Pos{x, 0})
+		if cl.ch != want || cl.fg != ColYellow || cl.bg != ColBlue {
+			t.Errorf("cell %d is %q in %s on %s, want %q in Yellow on Blue", x, cl.ch, ColorName(cl.fg), ColorName(cl.bg), want)
+		}
+	}
+	if got := c.Tile(Pos{1, 0}); got != TileFromRune('─') {
+		t.Errorf("painting changed the tile to %v", got)
+	}
+}
+
+func TestColorsSurviveEditing(t *testing.T) {
+	c := NewCanvas(0, 0, 20, 5)
+	defFg, defBg := c.Colors()
+	c.SetColors(ColLightRed, ColGreen)
+	c.SetRune(Pos{0, 0}, 'a')
+	c.SetColors(ColWhite|termbox.AttrBold, ColBlack)
+	c.SetRune(Pos{1, 0}, 'b')
+
+	c.Paste(Pos{0, 1}, c.Copy(Rect{0, 0, 2, 1}), false)
+	check := func(what string, p Pos, fg, bg termbox.Attribute) {
+		t.Helper()
+		if cl := c.cellAt(p); cl.fg != fg || cl.bg != bg {
+			t.Errorf("%s: %v is %s on %s, want %s on %s", what, p, ColorName(cl.fg), ColorName(cl.bg), ColorName(fg), ColorName(bg))
+		}
+	}
+	check("paste", Pos{0, 1}, ColLightRed, ColGreen)
+	check("paste", Pos{1, 1}, ColWhite|termbox.AttrBold, ColBlack)
+
+	c.SetColors(ColYellow, ColBlue)
+	c.Paint(Pos{0, 1})
+	check("paint", Pos{0, 1}, ColYellow, ColBlue)
+	c.Undo()
+	check("undo", Pos{0, 1}, ColLightRed, ColGreen)
+	c.Undo()
+	check("undo", Pos{0, 1}, defFg, defBg)
+	c.Redo()
+	check("redo", Pos{0, 1}, ColLightRed, ColGreen)
+}
+
+func TestColorDialog(t *testing.T) {
+	t.Parallel()
+	s := NewMemScreen(60, 12)
+	Puts(s, 0, 0, "background", ColWhite, ColBlack)
+	before := s.Lines()
+
+	// Foreground two colors to the right, background one to the left of
+	// Blue, wrapping around
+	s.PushKeys(termbox.KeyArrowRight, termbox.KeyArrowRight, termbox.KeyTab, termbox.KeyArrowLeft, termbox.KeyArrowLeft, termbox.KeyEnter)
+	fg, bg, ok := ColorDialog(s, ColBlack, ColBlue)
+	if !ok || fg != ColGreen || bg != ColWhite {
+		t.Errorf("ColorDialog returned %s, %s, %t; want Green, White, true", ColorName(fg), ColorName(bg), ok)
+	}
+	if got := s.Lines(); !reflect.DeepEqual(got, before) {
+		t.Errorf("screen not restored:\n%q", got)
+	}
+
+	s.PushKeys(termbox.KeyArrowRight)
+	s.PushEvents(termbox.Event{Type: termbox.EventKey, Mod: termbox.ModAlt})
+	if fg, bg, ok := ColorDialog(s, ColRed, ColBlack); ok || fg != ColRed || bg != ColBlack {
+		t.Errorf("cancelled ColorDialog returned %s, %s, %t; want Red, Black, false", ColorName(fg), ColorName(bg), ok)
+	}
+}
@@ -0,0 +1,271 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/asig/termbox-go"
+)
+
+// Native termdraw documents are JSON files that keep everything plain text
+// loses: colors, text attributes and tiles. Every row stores its text, the
+// tile patterns of its cells (4 characters per cell, or 8 if the row has
+// diagonal arms, see parseTile), and its colors as runs of cells sharing the
+// same colors. Tiles and runs are left out if a row doesn't have any.
+// Version 1 documents have no diagonal arms.
+
+const (
+	DocumentExt = ".tdraw"
+
+	documentFormat  = "termdraw"
+	documentVersion = 2
+)
+
+type document struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	Fg      string `json:"fg"`
+	Bg      string `json:"bg"`
+	Cursor  struct {
+		X int `json:"x"`
+		Y int `json:"y"`
+	} `json:"cursor"`
+
+	Rows []documentRow `json:"rows"`
+}
+
+type documentRow struct {
+	Text  string        `json:"text"`
+	Tiles string        `json:"tiles,omitempty"`
+	Runs  []documentRun `json:"runs,omitempty"`
+}
+
+type documentRun struct {
+	N       int      `json:"n"`
+	Fg      string   `json:"fg"`
+	Bg      string   `json:"bg"`
+	FgAttrs []string `json:"fgAttrs,omitempty"`
+	BgAttrs []string `json:"bgAttrs,omitempty"`
+}
+
+// IsDocument checks whether data looks like a native termdraw document.
+func IsDocument(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		return false
+	}
+	var header struct {
+		Format string `json:"format"`
+	}
+	return json.Unmarshal(data, &header) == nil && header.Format == documentFormat
+}
+
+func attrList(attrs termbox.Attribute) []string {
+	var res []string
+	for _, an := range attrNames {
+		if attrs&an.attr != 0 {
+			res = append(res, an.name)
+		}
+	}
+	return res
+}
+
+func parseAttrList(names []string) (termbox.Attribute, error) {
+	var attrs termbox.Attribute
+outer:
+	for _, n := range names {
+		for _, an := range attrNames {
+			if an.name == n {
+				attrs |= an.attr
+				continue outer
+			}
+		}
+		return 0, fmt.Errorf("unknown attribute %q", n)
+	}
+	return attrs, nil
+}
+
+func newDocumentRun(cl cell) documentRun {
+	fg, fgAttrs := splitAttribute(cl.fg)
+	bg, bgAttrs := splitAttribute(cl.bg)
+	return documentRun{
+		N:       1,
+		Fg:      colorHex(fg),
+		Bg:      colorHex(bg),
+		FgAttrs: attrList(fgAttrs),
+		BgAttrs: attrList(bgAttrs),
+	}
+}
+
+// WriteDocument writes the canvas in the native termdraw format.
+func (c *Canvas) WriteDocument(w io.Writer) error {
+	width, height := c.Extent()
+	doc := document{
+		Format:  documentFormat,
+		Version: documentVersion,
+		Width:   width,
+		Height:  height,
+		Fg:      colorHex(c.fg),
+		Bg:      colorHex(c.bg),
+		Rows:    make([]documentRow, 0, height),
+	}
+	doc.Cursor.X, doc.Cursor.Y = c.cX, c.cY
+
+	// Documents only use a few different tiles
+	patterns := make(map[Tile]string)
+
+	// Trailing blank cells and rows carry no information
+	for _, row := range c.trimmedRows() {
+		var dr documentRow
+		var text, tiles bytes.Buffer
+		hasTiles, hasColors, hasDiagonals := false, false, false
+		for _, cl := range row {
+			hasDiagonals = hasDiagonals || cl.tile.diagonal() != 0
+		}
+		for i, cl := range row {
+			text.WriteRune(cl.ch)
+			p, ok := patterns[cl.tile]
+			if !ok {
+				p = cl.tile.pattern()
+				patterns[cl.tile] = p
+			}
+			if hasDiagonals && len(p) < len(patternDirs) {
+				p += "    "
+			}
+			tiles.WriteString(p)
+			hasTiles = hasTiles || cl.tile != 0
+			hasColors = hasColors || cl.fg != c.fg || cl.bg != c.bg
+			if i > 0 && cl.fg == row[i-1].fg && cl.bg == row[i-1].bg {
+				dr.Runs[len(dr.Runs)-1].N++
+			} else {
+				dr.Runs = append(dr.Runs, newDocumentRun(cl))
+			}
+		}
+		dr.Text = text.String()
+		if hasTiles {
+			dr.Tiles = tiles.String()
+		}
+		if !hasColors {
+			dr.Runs = nil
+		}
+		doc.Rows = append(doc.Rows, dr)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// ReadDocument replaces the canvas' content with a document in the native
+// termdraw format.
+func (c *Canvas) ReadDocument(r io.Reader) error {
+	var doc document
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return fmt.Errorf("can't parse document: %w", err)
+	}
+	if doc.Format != documentFormat {
+		return fmt.Errorf("not a termdraw document")
+	}
+	if doc.Version < 1 || doc.Version > documentVersion {
+		return fmt.Errorf("unsupported document version %d", doc.Version)
+	}
+
+	fg, err := parseColorHex(doc.Fg)
+	if err != nil {
+		return err
+	}
+	bg, err := parseColorHex(doc.Bg)
+	if err != nil {
+		return err
+	}
+
+	tiles := make(map[string]Tile)
+	rows := make([][]cell, len(doc.Rows))
+	for y, dr := range doc.Rows {
+		n := utf8.RuneCountInString(dr.Text)
+		row := make([]cell, 0, n)
+		for _, ch := range dr.Text {
+			row = append(row, cell{ch: ch, fg: fg, bg: bg})
+		}
+
+		if dr.Tiles != "" {
+			p := len(patternDirs)
+			if len(dr.Tiles) != n*p {
+				p = 4
+			}
+			if len(dr.Tiles) != n*p {
+				return fmt.Errorf("row %d: tiles don't match text", y)
+			}
+			for x := range row {
+				pattern := dr.Tiles[x*p : (x+1)*p]
+				t, ok := tiles[pattern]
+				if !ok {
+					t, err = parseTile(pattern)
+					if err != nil {
+						return fmt.Errorf("row %d: %w", y, err)
+					}
+					tiles[pattern] = t
+				}
+				row[x].tile = t
+			}
+		}
+
+		x := 0
+		for _, run := range dr.Runs {
+			if run.N < 0 || x+run.N > n {
+				return fmt.Errorf("row %d: color runs don't match text", y)
+			}
+			runFg, err := parseColorHex(run.Fg)
+			if err != nil {
+				return fmt.Errorf("row %d: %w", y, err)
+			}
+			runBg, err := parseColorHex(run.Bg)
+			if err != nil {
+				return fmt.Errorf("row %d: %w", y, err)
+			}
+			fgAttrs, err := parseAttrList(run.FgAttrs)
+			if err != nil {
+				return fmt.Errorf("row %d: %w", y, err)
+			}
+			bgAttrs, err := parseAttrList(run.BgAttrs)
+			if err != nil {
+				return fmt.Errorf("row %d: %w", y, err)
+			}
+			for i := x; i < x+run.N; i++ {
+				row[i].fg = runFg | fgAttrs
+				row[i].bg = runBg | bgAttrs
+			}
+			x += run.N
+		}
+		rows[y] = row
+	}
+
+	c.fg, c.bg = fg, bg
+	c.setRows(rows)
+	c.SetPos(Pos{doc.Cursor.X, doc.Cursor.Y})
+	return nil
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/asig/termbox-go"
+)
+
+func TestDocumentRoundTrip(t *testing.T) {
+	c := NewCanvas(0, 0, 20, 10)
+	c.SetText([]string{"", "  text"})
+	c.SetColors(ColYellow|termbox.AttrBold|termbox.AttrUnderline, ColBlue|termbox.AttrReverse)
+	c.DrawBox(Rect{0, 0, 4, 3}, BorderStyle_Rounded)
+	c.SetColors(ColWhite, ColBlack)
+	// Heavy meets double, which has no glyph of its own
+	c.strokeN(Pos{4, 3}, DirRight, 2, BorderStyle_Heavy)
+	c.strokeN(Pos{5, 3}, DirDown, 1, BorderStyle_Double)
+	c.strokeN(Pos{6, 2}, DirDownRight, 1, BorderStyle_LightDoubleDash)
+	c.SetPos(Pos{3, 4})
+
+	var buf bytes.Buffer
+	if err := c.WriteDocument(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !IsDocument(buf.Bytes()) {
+		t.Errorf("IsDocument() = false")
+	}
+	c2 := NewCanvas(0, 0, 20, 10)
+	if err := c2.ReadDocument(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c2.trimmedRows(), c.trimmedRows(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", trimmedText(c2), trimmedText(c))
+	}
+	if got := c2.Pos(); got != (Pos{3, 4}) {
+		t.Errorf("cursor at %v, want 3,4", got)
+	}
+	if got := c2.Tile(Pos{5, 3}); got != newTile("h hd") {
+		t.Errorf("tile at 5,3 is %q, want \"h hd\"", got.pattern())
+	}
+}
+
+func TestReadDocumentV1(t *testing.T) {
+	f, err := os.Open("testdata/v1.tdraw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	c := NewCanvas(0, 0, 20, 10)
+	if err := c.ReadDocument(f); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"╭──╮",
+		"│hi│",
+		"╰──╯",
+	}
+	if got := c.AsText(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := c.Tile(Pos{0, 0}); got != newTile("  rr") {
+		t.Errorf("tile at 0,0 is %q, want \"  rr\"", got.pattern())
+	}
+	if got := c.cellAt(Pos{1, 1}); got.fg != ColRed|termbox.AttrBold || got.bg != ColBlack {
+		t.Errorf("cell 1,1 is %v/%v, want bold red on black", got.fg, got.bg)
+	}
+	if got := c.cellAt(Pos{3, 1}); got.fg != ColLightGrey {
+		t.Errorf("cell 3,1 is %v, want light grey", got.fg)
+	}
+	if got := c.Pos(); got != (Pos{2, 1}) {
+		t.Errorf("cursor at %v, want 2,1", got)
+	}
+}
+
+func TestReadDocumentErrors(t *testing.T) {
+	tests := []string{
+		`not json`,
+		`{"format": "other", "version": 1, "fg": "#aaaaaa", "bg": "#000000"}`,
+		`{"format": "termdraw", "version": 3, "fg": "#aaaaaa", "bg": "#000000"}`,
+		`{"format": "termdraw", "version": 2, "fg": "grey", "bg": "#000000"}`,
+		`{"format": "termdraw", "version": 2, "fg": "#aaaaaa", "bg": "#000000", "rows": [{"text": "ab", "tiles": "l l "}]}`,
+		`{"format": "termdraw", "version": 2, "fg": "#aaaaaa", "bg": "#000000", "rows": [{"text": "ab", "runs": [{"n": 3, "fg": "#aaaaaa", "bg": "#000000"}]}]}`,
+	}
+	for _, doc := range tests {
+		c := NewCanvas(0, 0, 20, 10)
+		if err := c.ReadDocument(bytes.NewBufferString(doc)); err == nil {
+			t.Errorf("ReadDocument(%s) succeeded", doc)
+		}
+	}
+}
@@ -26,36 +26,37 @@ import (
 )
 
 type EditField struct {
+	screen  Screen
 	x, y, w int
-	fg, bg termbox.Attribute
+	fg, bg  termbox.Attribute
 }
 
-func NewEditField(x,y,w int, fg, bg termbox.Attribute) *EditField {
+func NewEditField(screen Screen, x,y,w int, fg, bg termbox.Attribute) *EditField {
 	return &EditField{
-		x:  x,
-		y:  y,
-		w:  w,
-		fg: fg,
-		bg: bg,
+		screen: screen,
+		x:      x,
+		y:      y,
+		w:      w,
+		fg:     fg,
+		bg:     bg,
 	}
 }
 
 func (e *EditField) Run() (string, bool) {
+	screen := e.screen
 	res := ""
 	crsr := 0
 	ofs := 0
 
-	Puts(e.x, e.y, strings.Repeat(" ", e.w), e.fg, e.bg)
-	termbox.SetCursor(e.x, e.y)
+	Puts(screen, e.x, e.y, strings.Repeat(" ", e.w), e.fg, e.bg)
+	screen.SetCursor(e.x, e.y)
 
-	termbox.Flush()
+	screen.Flush()
 
 	var ok bool
 	quit := false
 	for !quit {
-		data := make([]byte, 30)
-		termbox.PollRawEvent(data)
-		ev := termbox.ParseEvent(data)
+		ev := screen.PollEvent()
 		resPos := crsr + ofs
 		switch ev.Type {
 		case termbox.EventKey:
@@ -114,9 +115,9 @@ func (e *EditField) Run() (string, bool) {
 		} else {
 			padded = padded[:e.w]
 		}
-		Puts(e.x, e.y, padded, e.fg, e.bg)
-		termbox.SetCursor(e.x +crsr, e.y)
-		termbox.Flush()
+		Puts(screen, e.x, e.y, padded, e.fg, e.bg)
+		screen.SetCursor(e.x +crsr, e.y)
+		screen.Flush()
 	}
 
 	return res, ok
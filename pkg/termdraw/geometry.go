@@ -0,0 +1,278 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+// Tiles can be drawn as vector graphics instead of glyphs: every arm is a
+// line from the cell's center to its edge, or to its corner for diagonal
+// arms, which lines up with the arm of the neighbouring cell.
+
+// tileMetrics defines the size of a cell and its lines.
+type tileMetrics struct {
+	// Size of a cell
+	w, h float64
+	// Where the lines cross
+	cx, cy float64
+	// Line widths
+	light, heavy, double float64
+	// Distance of the two lines of a double border from the center
+	gap float64
+}
+
+// tileStroke is a line or a quarter circle. Coordinates are relative to
+// the cell's top left corner.
+type tileStroke struct {
+	x0, y0, x1, y1 float64
+	width          float64
+
+	// For arcs: the circle's center and radius
+	arc    bool
+	ax, ay float64
+	r      float64
+}
+
+func (m tileMetrics) width(bs BorderStyle) float64 {
+	switch bs.Solid() {
+	case BorderStyle_Heavy:
+		return m.heavy
+	case BorderStyle_Double:
+		return m.double
+	}
+	return m.light
+}
+
+// edgeDist returns the distance from the center to the edge of the cell in
+// direction d.
+func (m tileMetrics) edgeDist(d Direction) float64 {
+	switch d {
+	case DirUp:
+		return m.cy
+	case DirDown:
+		return m.h - m.cy
+	case DirLeft:
+		return m.cx
+	default:
+		return m.w - m.cx
+	}
+}
+
+// line returns a stroke in direction d, starting at from and ending at to,
+// both measured from the center along d. ofs shifts the line sideways, in
+// direction side.
+func (m tileMetrics) line(d Direction, from, to float64, side Direction, ofs, width float64) tileStroke {
+	dx, dy := d.delta()
+	sx, sy := side.delta()
+	ox, oy := m.cx+float64(sx)*ofs, m.cy+float64(sy)*ofs
+	return tileStroke{
+		x0:    ox + float64(dx)*from,
+		y0:    oy + float64(dy)*from,
+		x1:    ox + float64(dx)*to,
+		y1:    oy + float64(dy)*to,
+		width: width,
+	}
+}
+
+// dashes returns the dashes of an arm in direction d. A cell holds n dashes,
+// each followed by a gap of the same length, and the arm gets the ones, or
+// the parts of them, on its side of the center.
+func (m tileMetrics) dashes(d Direction, n int, width float64) []tileStroke {
+	l, c := m.w, m.cx
+	if d == DirUp || d == DirDown {
+		l, c = m.h, m.cy
+	}
+	period := l / float64(n)
+
+	var res []tileStroke
+	for i := 0; i < n; i++ {
+		a := float64(i) * period
+		b := a + period/2
+		// Distances from the center along d
+		from, to := a-c, b-c
+		if d == DirLeft || d == DirUp {
+			from, to = c-b, c-a
+		}
+		from = max2f(from, 0)
+		to = min2f(to, m.edgeDist(d))
+		if to > from {
+			res = append(res, m.line(d, from, to, d, 0, width))
+		}
+	}
+	return res
+}
+
+// diagonalArm returns the strokes of a diagonal arm in direction d. Double
+// lines are shifted sideways, so that they line up with the neighbouring
+// cells, and dashes are laid out along the cell's diagonal like they are
+// along its width or height for orthogonal arms.
+func (m tileMetrics) diagonalArm(d Direction, bs BorderStyle) []tileStroke {
+	dx, dy := d.delta()
+	ex, ey := 0.0, 0.0
+	if dx > 0 {
+		ex = m.w
+	}
+	if dy > 0 {
+		ey = m.h
+	}
+	w := m.width(bs)
+	// The part of the arm from a to b, as fractions of its length
+	part := func(a, b, ofs float64) tileStroke {
+		return tileStroke{
+			x0:    m.cx + (ex-m.cx)*a + ofs,
+			y0:    m.cy + (ey-m.cy)*a,
+			x1:    m.cx + (ex-m.cx)*b + ofs,
+			y1:    m.cy + (ey-m.cy)*b,
+			width: w,
+		}
+	}
+
+	if n := bs.Dashes(); n > 0 {
+		var res []tileStroke
+		period := 1 / float64(n)
+		for i := 0; i < n; i++ {
+			// Positions along the diagonal from the upper to the lower corner
+			s0 := float64(i) * period
+			s1 := s0 + period/2
+			from, to := 2*s0-1, 2*s1-1
+			if dy < 0 {
+				from, to = 1-2*s1, 1-2*s0
+			}
+			from, to = max2f(from, 0), min2f(to, 1)
+			if to > from {
+				res = append(res, part(from, to, 0))
+			}
+		}
+		return res
+	}
+	if bs == BorderStyle_Double {
+		return []tileStroke{part(0, 1, -m.gap), part(0, 1, m.gap)}
+	}
+	return []tileStroke{part(0, 1, 0)}
+}
+
+// arcCorner returns the two directions of t if t is a rounded corner.
+func (t Tile) arcCorner() (d1, d2 Direction, ok bool) {
+	if t.diagonal() != 0 {
+		return 0, 0, false
+	}
+	var arms []Direction
+	for _, d := range directions {
+		switch t.Dir(d) {
+		case BorderStyle_None:
+		case BorderStyle_Rounded:
+			arms = append(arms, d)
+		default:
+			return 0, 0, false
+		}
+	}
+	if len(arms) != 2 || arms[0].Inverse() == arms[1] {
+		return 0, 0, false
+	}
+	return arms[0], arms[1], true
+}
+
+// strokes returns the lines making up t.
+func (t Tile) strokes(m tileMetrics) []tileStroke {
+	var res []tileStroke
+
+	if d1, d2, ok := t.arcCorner(); ok {
+		r := min2f(min2f(m.edgeDist(d1), m.edgeDist(d2)), min2f(m.w, m.h)/2)
+		dx1, dy1 := d1.delta()
+		dx2, dy2 := d2.delta()
+		res = append(res,
+			m.line(d1, r, m.edgeDist(d1), d1, 0, m.light),
+			m.line(d2, r, m.edgeDist(d2), d2, 0, m.light),
+			tileStroke{
+				x0:    m.cx + float64(dx1)*r,
+				y0:    m.cy + float64(dy1)*r,
+				x1:    m.cx + float64(dx2)*r,
+				y1:    m.cy + float64(dy2)*r,
+				width: m.light,
+				arc:   true,
+				ax:    m.cx + float64(dx1+dx2)*r,
+				ay:    m.cy + float64(dy1+dy2)*r,
+				r:     r,
+			})
+		return res
+	}
+
+	hasDouble := false
+	for _, d := range directions {
+		hasDouble = hasDouble || t.Dir(d) == BorderStyle_Double
+	}
+
+	for _, d := range directions {
+		bs := t.Dir(d)
+		if bs == BorderStyle_None {
+			continue
+		}
+		w := m.width(bs)
+		end := m.edgeDist(d)
+		if n := bs.Dashes(); n > 0 {
+			res = append(res, m.dashes(d, n, w)...)
+			continue
+		}
+		if bs != BorderStyle_Double {
+			// Reach the far line of a crossing double border
+			ext := w / 2
+			if hasDouble {
+				ext = max2f(ext, m.gap)
+			}
+			res = append(res, m.line(d, -ext, end, d, 0, w))
+			continue
+		}
+
+		// Double borders: each of the two lines stops where it meets the
+		// borders on its side.
+		pa, pb := d.perpendicular()
+		for _, side := range []Direction{pa, pb} {
+			start := -m.gap
+			switch t.Dir(side) {
+			case BorderStyle_Double:
+				start = m.gap
+			case BorderStyle_None:
+				if o := t.Dir(side.Inverse()); o != BorderStyle_None && o != BorderStyle_Double {
+					start = 0
+				}
+			default:
+				start = 0
+			}
+			res = append(res, m.line(d, start-w/2, end, side, m.gap, w))
+		}
+	}
+
+	for _, d := range diagonalDirections {
+		if bs := t.Dir(d); bs != BorderStyle_None {
+			res = append(res, m.diagonalArm(d, bs)...)
+		}
+	}
+	return res
+}
+
+func min2f(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max2f(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,154 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+const maxHistoryDepth = 1000
+
+// change is a single reversible modification of the canvas.
+type change struct {
+	undo, redo func(c *Canvas)
+}
+
+// step is a group of changes that is undone and redone as a whole.
+type step struct {
+	changes             []change
+	posBefore, posAfter Pos
+}
+
+type history struct {
+	undo, redo []*step
+
+	// Currently open group, and how deeply BeginGroup calls are nested
+	cur   *step
+	level int
+
+	// Set while undoing or redoing, so that the replayed changes are not
+	// recorded again.
+	replaying bool
+}
+
+func (h *history) push(s *step) {
+	h.undo = append(h.undo, s)
+	if len(h.undo) > maxHistoryDepth {
+		h.undo = h.undo[len(h.undo)-maxHistoryDepth:]
+	}
+	h.redo = nil
+}
+
+// record adds a change that has just been applied to the canvas. Changes
+// outside of a group become an undo step of their own.
+func (c *Canvas) record(undo, redo func(c *Canvas)) {
+	h := &c.history
+	if h.replaying {
+		return
+	}
+	ch := change{undo: undo, redo: redo}
+	if h.cur != nil {
+		h.cur.changes = append(h.cur.changes, ch)
+		return
+	}
+	p := c.Pos()
+	h.push(&step{changes: []change{ch}, posBefore: p, posAfter: p})
+}
+
+// BeginGroup starts collecting all following changes into one undo step,
+// until the matching EndGroup is called. Groups can be nested.
+func (c *Canvas) BeginGroup() {
+	h := &c.history
+	if h.level == 0 {
+		h.cur = &step{posBefore: c.Pos()}
+	}
+	h.level++
+}
+
+func (c *Canvas) EndGroup() {
+	h := &c.history
+	if h.level == 0 {
+		return
+	}
+	h.level--
+	if h.level > 0 {
+		return
+	}
+	s := h.cur
+	h.cur = nil
+	if len(s.changes) > 0 {
+		s.posAfter = c.Pos()
+		h.push(s)
+	}
+}
+
+// Undo reverts the most recent undo step. It returns false if there is
+// nothing to undo.
+func (c *Canvas) Undo() bool {
+	h := &c.history
+	if len(h.undo) == 0 {
+		return false
+	}
+	s := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+
+	// A block being moved doesn't match the canvas anymore
+	c.dropFloating()
+	h.replaying = true
+	for i := len(s.changes) - 1; i >= 0; i-- {
+		s.changes[i].undo(c)
+	}
+	h.replaying = false
+	c.SetPos(s.posBefore)
+
+	h.redo = append(h.redo, s)
+	return true
+}
+
+// Redo re-applies the most recently undone step. It returns false if there
+// is nothing to redo.
+func (c *Canvas) Redo() bool {
+	h := &c.history
+	if len(h.redo) == 0 {
+		return false
+	}
+	s := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+
+	c.dropFloating()
+	h.replaying = true
+	for _, ch := range s.changes {
+		ch.redo(c)
+	}
+	h.replaying = false
+	c.SetPos(s.posAfter)
+
+	h.undo = append(h.undo, s)
+	return true
+}
+
+func (c *Canvas) UndoDepth() int {
+	return len(c.history.undo)
+}
+
+func (c *Canvas) RedoDepth() int {
+	return len(c.history.redo)
+}
+
+// ResetHistory forgets all undo and redo steps, e.g. after loading a new
+// document.
+func (c *Canvas) ResetHistory() {
+	c.history = history{}
+}
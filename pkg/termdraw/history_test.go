@@ -0,0 +1,121 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUndoRedo(t *testing.T) {
+	c := NewCanvas(0, 0, 10, 5)
+	if c.Undo() || c.Redo() {
+		t.Errorf("undo or redo with empty history")
+	}
+	c.SetRune(Pos{0, 0}, 'a')
+	c.SetRune(Pos{1, 0}, 'b')
+	c.Undo()
+	if got := trimmedText(c); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("after undo, got %q", got)
+	}
+	c.Redo()
+	if got := trimmedText(c); !reflect.DeepEqual(got, []string{"ab"}) {
+		t.Errorf("after redo, got %q", got)
+	}
+
+	// A new edit forgets the undone steps
+	c.Undo()
+	c.SetRune(Pos{1, 0}, 'c')
+	if c.RedoDepth() != 0 || c.Redo() {
+		t.Errorf("redo still possible after new edit")
+	}
+	if got := trimmedText(c); !reflect.DeepEqual(got, []string{"ac"}) {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestNestedGroups(t *testing.T) {
+	c := NewCanvas(0, 0, 10, 5)
+	c.BeginGroup()
+	c.SetRune(Pos{0, 0}, 'a')
+	c.BeginGroup()
+	c.SetRune(Pos{1, 0}, 'b')
+	c.EndGroup()
+	if c.UndoDepth() != 0 {
+		t.Errorf("inner EndGroup closed the outer group")
+	}
+	c.SetRune(Pos{2, 0}, 'c')
+	c.EndGroup()
+	if c.UndoDepth() != 1 {
+		t.Errorf("undo depth is %d, want 1", c.UndoDepth())
+	}
+	c.Undo()
+	if got := c.trimmedRows(); len(got) != 0 {
+		t.Errorf("group not undone in one step: %q", trimmedText(c))
+	}
+	c.Redo()
+	if got := trimmedText(c); !reflect.DeepEqual(got, []string{"abc"}) {
+		t.Errorf("after redo, got %q", got)
+	}
+
+	// Groups without changes leave no step, and unbalanced EndGroups are
+	// ignored
+	c.BeginGroup()
+	c.EndGroup()
+	c.EndGroup()
+	if c.UndoDepth() != 1 {
+		t.Errorf("undo depth is %d, want 1", c.UndoDepth())
+	}
+}
+
+func TestHistoryDepth(t *testing.T) {
+	c := NewCanvas(0, 0, 10, 5)
+	for i := 0; i < maxHistoryDepth+5; i++ {
+		c.SetRune(Pos{i % 10, i / 10}, 'x')
+	}
+	if c.UndoDepth() != maxHistoryDepth {
+		t.Errorf("undo depth is %d, want %d", c.UndoDepth(), maxHistoryDepth)
+	}
+	for c.Undo() {
+	}
+	// The oldest steps were dropped
+	if got := trimmedText(c); !reflect.DeepEqual(got, []string{strings.Repeat("x", 5)}) {
+		t.Errorf("after undoing everything, got %q", got)
+	}
+}
+
+func TestUndoRestoresPosition(t *testing.T) {
+	c := NewCanvas(0, 0, 10, 5)
+	c.SetPos(Pos{1, 1})
+	c.BeginGroup()
+	c.SetRune(Pos{1, 1}, 'a')
+	c.SetPos(Pos{4, 2})
+	c.EndGroup()
+	c.SetPos(Pos{7, 3})
+
+	c.Undo()
+	if got := c.Pos(); got != (Pos{1, 1}) {
+		t.Errorf("after undo, cursor at %v, want 1,1", got)
+	}
+	c.Redo()
+	if got := c.Pos(); got != (Pos{4, 2}) {
+		t.Errorf("after redo, cursor at %v, want 4,2", got)
+	}
+}
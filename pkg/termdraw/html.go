@@ -0,0 +1,154 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/asig/termbox-go"
+)
+
+const htmlClassPrefix = "td-"
+
+type HTMLOptions struct {
+	// Use CSS classes for the palette colors and text attributes instead
+	// of inline styles. The classes are defined in a <style> element in
+	// front of the <pre> element.
+	Classes bool
+	// Write a complete HTML page instead of just the <pre> element.
+	Standalone bool
+}
+
+var htmlAttrStyles = []struct {
+	attr  termbox.Attribute
+	class string
+	style string
+}{
+	{termbox.AttrBold, "bold", "font-weight:bold"},
+	{termbox.AttrDim, "dim", "opacity:0.6"},
+	{termbox.AttrCursive, "cursive", "font-style:italic"},
+	{termbox.AttrUnderline, "underline", "text-decoration:underline"},
+	{termbox.AttrBlink, "blink", "text-decoration:blink"},
+}
+
+// htmlStyle returns the CSS classes and inline styles for a cell with the
+// given colors.
+func htmlStyle(fg, bg termbox.Attribute, useClasses bool) (classes, styles []string) {
+	fg, bg, attrs := resolveColors(fg, bg)
+
+	if i := paletteIndex(fg); useClasses && i >= 0 {
+		classes = append(classes, fmt.Sprintf("%sfg-%d", htmlClassPrefix, i))
+	} else {
+		styles = append(styles, "color:"+colorHex(fg))
+	}
+	if i := paletteIndex(bg); useClasses && i >= 0 {
+		classes = append(classes, fmt.Sprintf("%sbg-%d", htmlClassPrefix, i))
+	} else {
+		styles = append(styles, "background-color:"+colorHex(bg))
+	}
+	for _, as := range htmlAttrStyles {
+		if attrs&as.attr == 0 {
+			continue
+		}
+		if useClasses {
+			classes = append(classes, htmlClassPrefix+as.class)
+		} else {
+			styles = append(styles, as.style)
+		}
+	}
+	return classes, styles
+}
+
+func htmlStyleSheet() string {
+	var sb strings.Builder
+	sb.WriteString("<style>\n")
+	for i, col := range Palette {
+		fmt.Fprintf(&sb, ".%sfg-%d { color: %s; }\n", htmlClassPrefix, i, colorHex(col))
+	}
+	for i, col := range Palette {
+		fmt.Fprintf(&sb, ".%sbg-%d { background-color: %s; }\n", htmlClassPrefix, i, colorHex(col))
+	}
+	for _, as := range htmlAttrStyles {
+		fmt.Fprintf(&sb, ".%s%s { %s; }\n", htmlClassPrefix, as.class, strings.Replace(as.style, ":", ": ", 1))
+	}
+	sb.WriteString("</style>\n")
+	return sb.String()
+}
+
+// ExportHTML writes the canvas as a <pre> element, with the colors set
+// with <span> elements. Text in the canvas' default colors doesn't get a
+// <span>, the <pre> element's style takes care of it.
+func (c *Canvas) ExportHTML(w io.Writer, opts HTMLOptions) error {
+	bw := bufio.NewWriter(w)
+
+	if opts.Standalone {
+		bw.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+		if opts.Classes {
+			bw.WriteString(htmlStyleSheet())
+		}
+		bw.WriteString("</head>\n<body>\n")
+	} else if opts.Classes {
+		bw.WriteString(htmlStyleSheet())
+	}
+
+	preStyle := fmt.Sprintf("color:%s;background-color:%s;font-family:monospace;line-height:1", colorHex(c.fg), colorHex(c.bg))
+	fmt.Fprintf(bw, "<pre class=\"termdraw\" style=\"%s\">", preStyle)
+
+	for y, row := range c.trimmedRows() {
+		if y > 0 {
+			bw.WriteByte('\n')
+		}
+		for start := 0; start < len(row); {
+			end := start + 1
+			for end < len(row) && row[end].fg == row[start].fg && row[end].bg == row[start].bg {
+				end++
+			}
+			var text strings.Builder
+			for _, cl := range row[start:end] {
+				text.WriteRune(cl.ch)
+			}
+			escaped := html.EscapeString(text.String())
+
+			if row[start].fg == c.fg && row[start].bg == c.bg {
+				bw.WriteString(escaped)
+			} else {
+				classes, styles := htmlStyle(row[start].fg, row[start].bg, opts.Classes)
+				bw.WriteString("<span")
+				if len(classes) > 0 {
+					fmt.Fprintf(bw, " class=\"%s\"", strings.Join(classes, " "))
+				}
+				if len(styles) > 0 {
+					fmt.Fprintf(bw, " style=\"%s\"", strings.Join(styles, ";"))
+				}
+				fmt.Fprintf(bw, ">%s</span>", escaped)
+			}
+			start = end
+		}
+	}
+	bw.WriteString("</pre>\n")
+
+	if opts.Standalone {
+		bw.WriteString("</body>\n</html>\n")
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExportHTMLEscaping(t *testing.T) {
+	c := NewCanvas(0, 0, 20, 5)
+	c.SetText([]string{`a<b & "c"`})
+	c.SetColors(ColRed, ColBlack)
+	for i, ch := range `<&">` {
+		c.SetRune(Pos{i, 1}, ch)
+	}
+	tests := []struct {
+		opts HTMLOptions
+		span string
+	}{
+		{HTMLOptions{}, `<span style="color:#aa0000;background-color:#000000">&lt;&amp;&#34;&gt;</span>`},
+		{HTMLOptions{Classes: true}, `<span class="td-fg-4 td-bg-0">&lt;&amp;&#34;&gt;</span>`},
+		{HTMLOptions{Classes: true, Standalone: true}, `<span class="td-fg-4 td-bg-0">&lt;&amp;&#34;&gt;</span>`},
+	}
+	for _, tc := range tests {
+		var buf bytes.Buffer
+		if err := c.ExportHTML(&buf, tc.opts); err != nil {
+			t.Fatal(err)
+		}
+		got := buf.String()
+		if want := `>a&lt;b &amp; &#34;c&#34;` + "\n"; !strings.Contains(got, want) {
+			t.Errorf("%+v: plain text not escaped: %q", tc.opts, got)
+		}
+		if !strings.Contains(got, tc.span) {
+			t.Errorf("%+v: colored text not escaped: %q, want %q", tc.opts, got, tc.span)
+		}
+		if n := strings.Count(got, "<"); n != strings.Count(got, ">") {
+			t.Errorf("%+v: unbalanced markup: %q", tc.opts, got)
+		}
+		if strings.Contains(got, "a<b") || strings.Contains(got, `"c"`) {
+			t.Errorf("%+v: raw text in output: %q", tc.opts, got)
+		}
+	}
+}
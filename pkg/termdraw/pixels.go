@@ -0,0 +1,334 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"math"
+
+	"github.com/asig/termbox-go"
+)
+
+// In pixel mode, cells are split into pixels drawn with block elements or
+// Braille patterns, so that the drawing stays plain text. Pixels are
+// addressed by their own coordinates; pixels that aren't set have the
+// background color of the canvas.
+
+type PixelMode uint8
+
+const (
+	// 1x2 pixels per cell, drawn with '▀', '▄' and '█'. Every pixel has
+	// its own color.
+	PixelHalf PixelMode = iota
+	// 2x2 pixels per cell, drawn with the quadrant blocks. All pixels of a
+	// cell that are set have the same color.
+	PixelQuadrant
+	// 2x4 dots per cell, drawn with Braille patterns. All dots of a cell
+	// that are set have the same color.
+	PixelBraille
+)
+
+var (
+	pixelModeNames = map[PixelMode]string{
+		PixelHalf:     "1x2",
+		PixelQuadrant: "2x2",
+		PixelBraille:  "2x4",
+	}
+
+	// Quadrant blocks, indexed by their pixels: 1 is the upper left, 2 the
+	// upper right, 4 the lower left and 8 the lower right one.
+	quadrants = [16]rune{' ', '▘', '▝', '▀', '▖', '▌', '▞', '▛', '▗', '▚', '▐', '▜', '▄', '▙', '▟', '█'}
+
+	quadrantMasks = func() map[rune]uint8 {
+		res := make(map[rune]uint8)
+		for m, r := range quadrants {
+			res[r] = uint8(m)
+		}
+		return res
+	}()
+
+	// Bits of the dots of Braille patterns, which are added to U+2800. The
+	// dots are numbered down the left and then the right column, with the
+	// bottom row last.
+	brailleBits = [4][2]uint8{{0x01, 0x08}, {0x02, 0x10}, {0x04, 0x20}, {0x40, 0x80}}
+)
+
+func (m PixelMode) String() string {
+	return pixelModeNames[m]
+}
+
+// Size returns the number of pixels per cell.
+func (m PixelMode) Size() (w, h int) {
+	switch m {
+	case PixelQuadrant:
+		return 2, 2
+	case PixelBraille:
+		return 2, 4
+	}
+	return 1, 2
+}
+
+// Cell returns the position of the cell holding pixel p.
+func (m PixelMode) Cell(p Pos) Pos {
+	w, h := m.Size()
+	return Pos{p.X / w, p.Y / h}
+}
+
+// mask returns the pixels of ch that are set, for the modes with one color
+// per cell.
+func (m PixelMode) mask(ch rune) uint8 {
+	if m == PixelBraille {
+		if ch >= 0x2800 && ch <= 0x28ff {
+			return uint8(ch - 0x2800)
+		}
+		return 0
+	}
+	return quadrantMasks[ch]
+}
+
+// glyph returns the rune showing the pixels in mask, for the modes with
+// one color per cell.
+func (m PixelMode) glyph(mask uint8) rune {
+	switch {
+	case mask == 0:
+		return ' '
+	case m == PixelBraille:
+		return 0x2800 + rune(mask)
+	}
+	return quadrants[mask]
+}
+
+// bit returns the bit of pixel p in the mask of its cell, for the modes
+// with one color per cell.
+func (m PixelMode) bit(p Pos) uint8 {
+	if m == PixelBraille {
+		return brailleBits[p.Y%4][p.X%2]
+	}
+	return 1 << uint(p.Y%2*2+p.X%2)
+}
+
+// Pixel returns the color of pixel p.
+func (c *Canvas) Pixel(m PixelMode, p Pos) termbox.Attribute {
+	cl := c.cellAt(m.Cell(p))
+	if m != PixelHalf {
+		if m.mask(cl.ch)&m.bit(p) != 0 {
+			return cl.fg
+		}
+		return cl.bg
+	}
+	top, bottom := halfPixels(cl)
+	if p.Y%2 == 0 {
+		return top
+	}
+	return bottom
+}
+
+// SetPixel sets pixel p to color col. In the modes with one color per cell,
+// this changes the color of all the cell's pixels that are set, and pixels
+// set before stay set.
+func (c *Canvas) SetPixel(m PixelMode, p Pos, col termbox.Attribute) {
+	if p.X < 0 || p.Y < 0 {
+		return
+	}
+	cp := m.Cell(p)
+	cl := c.cellAt(cp)
+	if m != PixelHalf {
+		// Text is overwritten
+		mask := m.mask(cl.ch)
+		if col == cl.bg {
+			mask &^= m.bit(p)
+		} else {
+			mask |= m.bit(p)
+			cl.fg = col
+		}
+		cl.ch = m.glyph(mask)
+		cl.tile = 0
+		c.setCell(cp, cl)
+		return
+	}
+	top, bottom := halfPixels(cl)
+	if p.Y%2 == 0 {
+		top = col
+	} else {
+		bottom = col
+	}
+	c.setCell(cp, c.halfCell(top, bottom))
+}
+
+// ClearPixel resets pixel p to the background color.
+func (c *Canvas) ClearPixel(m PixelMode, p Pos) {
+	if m == PixelHalf || p.X < 0 || p.Y < 0 {
+		c.SetPixel(m, p, c.bg)
+		return
+	}
+	cp := m.Cell(p)
+	cl := c.cellAt(cp)
+	cl.ch = m.glyph(m.mask(cl.ch) &^ m.bit(p))
+	cl.tile = 0
+	c.setCell(cp, cl)
+}
+
+// isSet tells whether pixel p is set.
+func (c *Canvas) isSet(m PixelMode, p Pos) bool {
+	if m == PixelHalf {
+		return c.Pixel(m, p) != c.bg
+	}
+	return m.mask(c.cellAt(m.Cell(p)).ch)&m.bit(p) != 0
+}
+
+// TogglePixel clears pixel p if it is set, and sets it to col otherwise.
+func (c *Canvas) TogglePixel(m PixelMode, p Pos, col termbox.Attribute) {
+	if c.isSet(m, p) {
+		c.ClearPixel(m, p)
+	} else {
+		c.SetPixel(m, p, col)
+	}
+}
+
+// halfPixels returns the colors of the upper and lower pixel of cl. Cells
+// that aren't half blocks are taken as their background color.
+func halfPixels(cl cell) (top, bottom termbox.Attribute) {
+	switch cl.ch {
+	case '▀':
+		return cl.fg, cl.bg
+	case '▄':
+		return cl.bg, cl.fg
+	case '█':
+		return cl.fg, cl.fg
+	}
+	return cl.bg, cl.bg
+}
+
+// halfCell returns a cell showing the pixels top and bottom.
+func (c *Canvas) halfCell(top, bottom termbox.Attribute) cell {
+	switch {
+	case top == bottom && top == c.bg:
+		return c.blank()
+	case top == bottom:
+		return cell{ch: '█', fg: top, bg: c.bg}
+	case top == c.bg:
+		return cell{ch: '▄', fg: bottom, bg: top}
+	}
+	return cell{ch: '▀', fg: top, bg: bottom}
+}
+
+// PixelLine draws a line of pixels from a to b.
+func (c *Canvas) PixelLine(m PixelMode, a, b Pos, col termbox.Attribute) {
+	c.BeginGroup()
+	defer c.EndGroup()
+
+	// Bresenham
+	dx, dy := abs(b.X-a.X), -abs(b.Y-a.Y)
+	sx, sy := 1, 1
+	if b.X < a.X {
+		sx = -1
+	}
+	if b.Y < a.Y {
+		sy = -1
+	}
+	err := dx + dy
+	for p := a; ; {
+		c.SetPixel(m, p, col)
+		if p == b {
+			break
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			p.X += sx
+		}
+		if e2 <= dx {
+			err += dx
+			p.Y += sy
+		}
+	}
+}
+
+// PixelRect draws the outline of the rectangle with the opposite corners a
+// and b.
+func (c *Canvas) PixelRect(m PixelMode, a, b Pos, col termbox.Attribute) {
+	c.BeginGroup()
+	defer c.EndGroup()
+
+	c.PixelLine(m, a, Pos{b.X, a.Y}, col)
+	c.PixelLine(m, Pos{b.X, a.Y}, b, col)
+	c.PixelLine(m, b, Pos{a.X, b.Y}, col)
+	c.PixelLine(m, Pos{a.X, b.Y}, a, col)
+}
+
+// PixelCircle draws a circle of pixels around center, going through p.
+func (c *Canvas) PixelCircle(m PixelMode, center, p Pos, col termbox.Attribute) {
+	c.BeginGroup()
+	defer c.EndGroup()
+
+	r := int(math.Round(math.Hypot(float64(p.X-center.X), float64(p.Y-center.Y))))
+	// Midpoint circle algorithm, drawing all eight octants at once
+	x, y, err := r, 0, 1-r
+	for x >= y {
+		for _, d := range [][2]int{{x, y}, {y, x}, {-y, x}, {-x, y}, {-x, -y}, {-y, -x}, {y, -x}, {x, -y}} {
+			c.SetPixel(m, Pos{center.X + d[0], center.Y + d[1]}, col)
+		}
+		y++
+		if err < 0 {
+			err += 2*y + 1
+		} else {
+			x--
+			err += 2*(y-x) + 1
+		}
+	}
+}
+
+// PixelFill sets the pixels that have the same color as p and are
+// connected to it to col. The filled area is limited to the content of the
+// canvas and the visible part of it.
+func (c *Canvas) PixelFill(m PixelMode, p Pos, col termbox.Attribute) {
+	old := c.Pixel(m, p)
+	if old == col || p.X < 0 || p.Y < 0 {
+		return
+	}
+	w, h := c.Extent()
+	pw, ph := m.Size()
+	bounds := Rect{W: max(w, c.ofsX+c.w) * pw, H: max(h, c.ofsY+c.h) * ph}
+
+	// Setting a pixel can change the color of others in the same cell, so
+	// the area is collected before it is painted.
+	var area []Pos
+	seen := map[Pos]bool{p: true}
+	todo := []Pos{p}
+	for len(todo) > 0 {
+		q := todo[len(todo)-1]
+		todo = todo[:len(todo)-1]
+		if !bounds.Contains(q) || c.Pixel(m, q) != old {
+			continue
+		}
+		area = append(area, q)
+		for _, d := range directions {
+			if n := q.Step(d); !seen[n] {
+				seen[n] = true
+				todo = append(todo, n)
+			}
+		}
+	}
+
+	c.BeginGroup()
+	defer c.EndGroup()
+
+	for _, q := range area {
+		c.SetPixel(m, q, col)
+	}
+}
@@ -0,0 +1,184 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHalfPixels(t *testing.T) {
+	c := NewCanvas(0, 0, 10, 5)
+	c.PixelRect(PixelHalf, Pos{0, 0}, Pos{4, 4}, ColRed)
+	want := []string{
+		"█▀▀▀█",
+		"█   █",
+		"▀▀▀▀▀",
+	}
+	if got := c.AsText(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Every pixel has its own color
+	c.SetPixel(PixelHalf, Pos{2, 1}, ColGreen)
+	if got := c.Pixel(PixelHalf, Pos{2, 0}); got != ColRed {
+		t.Errorf("pixel 2,0 is %v, want red", got)
+	}
+	if got := c.Pixel(PixelHalf, Pos{2, 1}); got != ColGreen {
+		t.Errorf("pixel 2,1 is %v, want green", got)
+	}
+	if got := c.cellAt(Pos{2, 0}); got.ch != '▀' || got.fg != ColRed || got.bg != ColGreen {
+		t.Errorf("cell 2,0 is %c %v/%v", got.ch, got.fg, got.bg)
+	}
+
+	// Clearing the pixels gives blank cells
+	c.ClearPixel(PixelHalf, Pos{0, 4})
+	c.ClearPixel(PixelHalf, Pos{0, 3})
+	if got := c.cellAt(Pos{0, 1}); got.ch != '▀' {
+		t.Errorf("cell 0,1 is %c, want ▀", got.ch)
+	}
+	c.ClearPixel(PixelHalf, Pos{0, 2})
+	if got := c.cellAt(Pos{0, 1}); got != c.blank() {
+		t.Errorf("cell 0,1 is %c, want blank", got.ch)
+	}
+}
+
+func TestQuadrantPixels(t *testing.T) {
+	c := NewCanvas(0, 0, 10, 5)
+	c.PixelLine(PixelQuadrant, Pos{0, 0}, Pos{5, 5}, ColRed)
+	c.PixelLine(PixelQuadrant, Pos{0, 5}, Pos{5, 0}, ColRed)
+	want := []string{
+		"▚ ▞",
+		" █",
+		"▞ ▚",
+	}
+	if got := c.AsText(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	c.ClearPixel(PixelQuadrant, Pos{0, 0})
+	if got := c.cellAt(Pos{0, 0}).ch; got != '▗' {
+		t.Errorf("cell 0,0 is %c, want ▗", got)
+	}
+}
+
+func TestPixelLine(t *testing.T) {
+	c := NewCanvas(0, 0, 10, 5)
+	c.PixelLine(PixelHalf, Pos{6, 3}, Pos{0, 0}, ColRed)
+	want := []string{
+		"▀▀▄▄",
+		"    ▀▀▄",
+	}
+	if got := c.AsText(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	c.Undo()
+	if got := c.trimmedRows(); len(got) != 0 {
+		t.Errorf("line not undone in one step")
+	}
+}
+
+func TestPixelFill(t *testing.T) {
+	c := NewCanvas(0, 0, 8, 4)
+	c.PixelRect(PixelHalf, Pos{0, 0}, Pos{4, 4}, ColRed)
+	c.PixelFill(PixelHalf, Pos{2, 2}, ColGreen)
+	if got := c.cellAt(Pos{2, 1}); got.ch != '█' || got.fg != ColGreen {
+		t.Errorf("cell 2,1 is %c %v/%v, want green", got.ch, got.fg, got.bg)
+	}
+	if got := c.Pixel(PixelHalf, Pos{2, 4}); got != ColRed {
+		t.Errorf("border was filled")
+	}
+	// Outside, the fill is limited to the visible area
+	c.PixelFill(PixelHalf, Pos{6, 0}, ColBlue)
+	if w, h := c.Extent(); w != 8 || h != 4 {
+		t.Errorf("extent after fill is %dx%d, want 8x4", w, h)
+	}
+	if got := c.Pixel(PixelHalf, Pos{0, 7}); got != ColBlue {
+		t.Errorf("pixel 0,7 wasn't filled")
+	}
+}
+
+func TestPixelFillRecolor(t *testing.T) {
+	c := NewCanvas(0, 0, 10, 5)
+	c.PixelLine(PixelQuadrant, Pos{0, 0}, Pos{9, 0}, ColRed)
+	c.PixelFill(PixelQuadrant, Pos{0, 0}, ColGreen)
+	for x := 0; x < 10; x++ {
+		if got := c.Pixel(PixelQuadrant, Pos{x, 0}); got != ColGreen {
+			t.Errorf("pixel %d,0 is %s, want Green", x, ColorName(got))
+		}
+	}
+	if got := c.AsText()[0]; got != "▀▀▀▀▀" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestBraille(t *testing.T) {
+	c := NewCanvas(0, 0, 10, 5)
+	c.PixelLine(PixelBraille, Pos{0, 0}, Pos{3, 7}, ColRed)
+	want := []string{"⢣", " ⢣"}
+	if got := c.AsText(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Dots are ORed together, and can be erased one by one
+	c.SetPixel(PixelBraille, Pos{1, 0}, ColRed)
+	if got := c.cellAt(Pos{0, 0}).ch; got != '⢫' {
+		t.Errorf("cell 0,0 is %c, want ⢫", got)
+	}
+	c.ClearPixel(PixelBraille, Pos{0, 0})
+	c.TogglePixel(PixelBraille, Pos{0, 1}, ColRed)
+	if got := c.cellAt(Pos{0, 0}).ch; got != '⢨' {
+		t.Errorf("cell 0,0 is %c, want ⢨", got)
+	}
+	c.TogglePixel(PixelBraille, Pos{0, 3}, ColRed)
+	if got := c.cellAt(Pos{0, 0}).ch; got != '⣨' {
+		t.Errorf("cell 0,0 is %c, want ⣨", got)
+	}
+	for _, p := range []Pos{{0, 3}, {1, 0}, {1, 2}, {1, 3}} {
+		c.ClearPixel(PixelBraille, p)
+	}
+	if got := c.cellAt(Pos{0, 0}); got.ch != ' ' {
+		t.Errorf("cell 0,0 is %c, want blank", got.ch)
+	}
+}
+
+func TestBrailleFillRecolor(t *testing.T) {
+	c := NewCanvas(0, 0, 10, 5)
+	c.PixelLine(PixelBraille, Pos{0, 1}, Pos{9, 1}, ColRed)
+	c.PixelFill(PixelBraille, Pos{9, 1}, ColGreen)
+	for x := 0; x < 10; x++ {
+		if got := c.Pixel(PixelBraille, Pos{x, 1}); got != ColGreen {
+			t.Errorf("dot %d,1 is %s, want Green", x, ColorName(got))
+		}
+	}
+	if got := c.AsText()[0]; got != "⠒⠒⠒⠒⠒" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestPixelCircle(t *testing.T) {
+	c := NewCanvas(0, 0, 10, 5)
+	c.PixelCircle(PixelBraille, Pos{4, 4}, Pos{4, 1}, ColRed)
+	want := []string{
+		"⢀⠔⠒⢄",
+		"⠘⢄⣀⠜",
+	}
+	if got := c.AsText(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,202 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/asig/termbox-go"
+)
+
+const (
+	fontW = 8
+	fontH = 16
+
+	fontUnderline = 13
+)
+
+// Pixel centers are at .5, so lines at x.5 are exactly one pixel wide.
+var pngMetrics = tileMetrics{
+	w: fontW, h: fontH,
+	cx: fontW / 2, cy: fontH / 2,
+	light: 2, heavy: 4, double: 1,
+	gap: 2.5,
+}
+
+func rgba(a termbox.Attribute) color.RGBA {
+	r, g, b := termbox.AttributeToRGB(a)
+	return color.RGBA{r, g, b, 0xff}
+}
+
+// covers checks whether the pixel center (x, y) lies on s.
+func (s tileStroke) covers(x, y float64) bool {
+	const eps = 1e-9
+	hw := s.width/2 + eps
+	if s.arc {
+		dx, dy := x-s.ax, y-s.ay
+		if dx*(s.x0-s.ax)+dy*(s.y0-s.ay) < 0 || dx*(s.x1-s.ax)+dy*(s.y1-s.ay) < 0 {
+			return false
+		}
+		return math.Abs(math.Hypot(dx, dy)-s.r) <= hw
+	}
+	lx, ly := s.x1-s.x0, s.y1-s.y0
+	l := math.Hypot(lx, ly)
+	if l == 0 {
+		return false
+	}
+	// Position along the line and distance from it
+	t := ((x-s.x0)*lx + (y-s.y0)*ly) / l
+	d := ((x-s.x0)*ly - (y-s.y0)*lx) / l
+	return t >= -eps && t <= l+eps && math.Abs(d) <= hw
+}
+
+// blockPixel tells whether pixel (x, y) of a block element or a Braille
+// pattern is set. ok is false if r is neither.
+func blockPixel(r rune, x, y int) (set, ok bool) {
+	const w, h = fontW, fontH
+	upper, lower, left, right := y < h/2, y >= h/2, x < w/2, x >= w/2
+	switch {
+	case r == '▀':
+		return upper, true
+	case r >= '▁' && r <= '█':
+		// Lower 1/8 to 8/8
+		return y >= h-int(r-'▁'+1)*h/8, true
+	case r >= '▉' && r <= '▏':
+		// Left 7/8 to 1/8
+		return x < int('▏'-r+1)*w/8, true
+	case r == '▐':
+		return right, true
+	case r >= '░' && r <= '▓':
+		// Shades with 1/4, 1/2 and 3/4 of the pixels set
+		switch r {
+		case '░':
+			return x%2 == 0 && y%2 == 0, true
+		case '▒':
+			return (x+y)%2 == 0, true
+		default:
+			return x%2 == 0 || y%2 == 0, true
+		}
+	case r == '▔':
+		return y < h/8, true
+	case r == '▕':
+		return x >= w-w/8, true
+	case r >= 0x2800 && r <= 0x28ff:
+		// 2x2 dots in the middle of a 2x4 grid
+		dx, dy := x%(w/2), y%(h/4)
+		if dx < 1 || dx > 2 || dy < 1 || dy > 2 {
+			return false, true
+		}
+		return uint8(r-0x2800)&brailleBits[y/(h/4)][x/(w/2)] != 0, true
+	}
+
+	ul, ur, ll, lr := upper && left, upper && right, lower && left, lower && right
+	switch r {
+	case '▖':
+		return ll, true
+	case '▗':
+		return lr, true
+	case '▘':
+		return ul, true
+	case '▙':
+		return ul || lower, true
+	case '▚':
+		return ul || lr, true
+	case '▛':
+		return upper || ll, true
+	case '▜':
+		return upper || lr, true
+	case '▝':
+		return ur, true
+	case '▞':
+		return ur || ll, true
+	case '▟':
+		return ur || lower, true
+	}
+	return false, false
+}
+
+// drawCell draws a cell with its top left corner at (x0, y0).
+func drawCell(img *image.RGBA, x0, y0 int, cl cell) {
+	fgAttr, bgAttr, attrs := resolveColors(cl.fg, cl.bg)
+	fg, bg := rgba(fgAttr), rgba(bgAttr)
+
+	t := cl.tile
+	if t == 0 && cl.ch >= 0x2500 && cl.ch < 0x2580 {
+		t = TileFromRune(cl.ch)
+	}
+	var strokes []tileStroke
+	if t != 0 {
+		strokes = t.strokes(pngMetrics)
+	}
+	glyph, hasGlyph := fontGlyphs[cl.ch]
+	if !hasGlyph {
+		glyph = fontGlyphs['\ufffd']
+	}
+
+	for y := 0; y < fontH; y++ {
+		row := glyph[y]
+		if attrs&termbox.AttrBold != 0 {
+			row |= row >> 1
+		}
+		if attrs&termbox.AttrUnderline != 0 && y == fontUnderline {
+			row = 0xff
+		}
+		for x := 0; x < fontW; x++ {
+			var set bool
+			if t != 0 {
+				for _, s := range strokes {
+					if set = s.covers(float64(x)+.5, float64(y)+.5); set {
+						break
+					}
+				}
+			} else if b, ok := blockPixel(cl.ch, x, y); ok {
+				set = b
+			} else {
+				set = row&(0x80>>x) != 0
+			}
+			if set {
+				img.SetRGBA(x0+x, y0+y, fg)
+			} else {
+				img.SetRGBA(x0+x, y0+y, bg)
+			}
+		}
+	}
+}
+
+// Image renders the canvas with an 8x16 bitmap font. Borders are drawn from
+// their tiles, so that they join seamlessly.
+func (c *Canvas) Image() *image.RGBA {
+	w, h := rowsExtent(c.trimmedRows())
+	img := image.NewRGBA(image.Rect(0, 0, w*fontW, h*fontH))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			drawCell(img, x*fontW, y*fontH, c.cellAt(Pos{x, y}))
+		}
+	}
+	return img
+}
+
+// ExportPNG writes the canvas as a PNG image.
+func (c *Canvas) ExportPNG(w io.Writer) error {
+	return png.Encode(w, c.Image())
+}
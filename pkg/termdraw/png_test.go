@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func TestExportPNG(t *testing.T) {
+	c := NewCanvas(0, 0, 20, 5)
+	c.SetText([]string{"─⠁", "", "  x  ", "", "   "})
+	var buf bytes.Buffer
+	if err := c.ExportPNG(&buf); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := img.Bounds(), image.Rect(0, 0, 3*fontW, 3*fontH); got != want {
+		t.Fatalf("image is %v, want %v", got, want)
+	}
+	fg, bg := rgba(c.fg), rgba(c.bg)
+	tests := []struct {
+		x, y int
+		want bool
+	}{
+		// The light line runs through the middle of the cell
+		{0, fontH / 2, true},
+		{fontW - 1, fontH/2 - 1, true},
+		{0, fontH/2 - 2, false},
+		{fontW / 2, 0, false},
+		// The upper left dot of the Braille pattern, and nothing else
+		{fontW + 1, 1, true},
+		{fontW + 2, 2, true},
+		{fontW + 1, fontH/4 + 1, false},
+		{fontW + fontW/2 + 1, 1, false},
+		{fontW, 0, false},
+	}
+	for _, tc := range tests {
+		want := bg
+		if tc.want {
+			want = fg
+		}
+		if got := img.At(tc.x, tc.y); got != want {
+			t.Errorf("pixel %d,%d is %v, want %v", tc.x, tc.y, got, want)
+		}
+	}
+}
+
+func TestFontCoversCP437(t *testing.T) {
+	for b := 0x20; b <= 0xff; b++ {
+		if b == 0x7f {
+			continue
+		}
+		r := cp437Rune(byte(b))
+		if _, ok := fontGlyphs[r]; ok {
+			continue
+		}
+		if r >= 0x2500 && r < 0x2580 && TileFromRune(r) != 0 {
+			continue
+		}
+		if _, ok := blockPixel(r, 0, 0); ok {
+			continue
+		}
+		t.Errorf("no glyph for %q (0x%02x)", r, b)
+	}
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+// restyleTile changes all arms of the tile at p that have style from to
+// style to. With from == BorderStyle_None, all arms are changed.
+func (c *Canvas) restyleTile(p Pos, from, to BorderStyle) {
+	t := c.Tile(p)
+	nt := t
+	for _, d := range allDirections {
+		if bs := t.Dir(d); bs != BorderStyle_None && (from == BorderStyle_None || bs == from) {
+			nt = nt.WithDir(d, to)
+		}
+	}
+	if nt != t {
+		c.replaceTile(p, nt)
+	}
+}
+
+// restyle changes all arms of the tiles in r that have style from to style
+// to. With from == BorderStyle_None, all arms are changed.
+func (c *Canvas) restyle(r Rect, from, to BorderStyle) {
+	for y := r.Y; y < r.Y+r.H; y++ {
+		for x := r.X; x < r.X+r.W; x++ {
+			c.restyleTile(Pos{x, y}, from, to)
+		}
+	}
+}
+
+// Restyle changes the borders in r from style from to style to. With from
+// == BorderStyle_None, all borders are changed.
+func (c *Canvas) Restyle(r Rect, from, to BorderStyle) {
+	c.BeginGroup()
+	defer c.EndGroup()
+
+	c.restyle(r, from, to)
+}
+
+// component returns the cells of the borders connected to the one at p,
+// following the arms of their tiles.
+func (c *Canvas) component(p Pos) []Pos {
+	if c.Tile(p) == 0 {
+		return nil
+	}
+	res := []Pos{p}
+	seen := map[Pos]bool{p: true}
+	for i := 0; i < len(res); i++ {
+		q := res[i]
+		t := c.Tile(q)
+		for _, d := range allDirections {
+			n := q.Step(d)
+			if t.Dir(d) == BorderStyle_None || n.X < 0 || n.Y < 0 || seen[n] {
+				continue
+			}
+			if c.Tile(n).Dir(d.Inverse()) != BorderStyle_None {
+				res = append(res, n)
+				seen[n] = true
+			}
+		}
+	}
+	return res
+}
+
+// RestyleComponent changes the borders connected to the one at p from
+// style from to style to, like Restyle.
+func (c *Canvas) RestyleComponent(p Pos, from, to BorderStyle) {
+	c.BeginGroup()
+	defer c.EndGroup()
+
+	for _, q := range c.component(p) {
+		c.restyleTile(q, from, to)
+	}
+}
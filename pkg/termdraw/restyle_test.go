@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"reflect"
+	"testing"
+)
+
+func restyleCanvas() *Canvas {
+	c := NewCanvas(0, 0, 20, 10)
+	c.SetText([]string{
+		"┌──┐ ╔══╗",
+		"│  ├─╢  ║",
+		"└──┘ ╚══╝",
+		"",
+		"┏━━┓",
+		"┗━━┛",
+	})
+	return c
+}
+
+func TestRestyle(t *testing.T) {
+	c := restyleCanvas()
+	c.Restyle(Rect{0, 0, 10, 3}, BorderStyle_Light, BorderStyle_Heavy)
+	want := []string{
+		"┏━━┓ ╔══╗",
+		"┃  ┣━╢  ║",
+		"┗━━┛ ╚══╝",
+		"",
+		"┏━━┓",
+		"┗━━┛",
+	}
+	if got := c.AsText(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	// Heavy meets double at 5,1, which has no glyph
+	if got := c.Tile(Pos{5, 1}); got != newTile("hd d") {
+		t.Errorf("tile at 5,1 is %q, want \"hd d\"", got.pattern())
+	}
+	c.Undo()
+	if got := c.AsText(); !reflect.DeepEqual(got, restyleCanvas().AsText()) {
+		t.Errorf("after undo, got %q", got)
+	}
+
+	// All styles
+	c.Restyle(Rect{0, 0, 10, 6}, BorderStyle_None, BorderStyle_Rounded)
+	want = []string{
+		"╭──╮ ╭──╮",
+		"│  ├─┤  │",
+		"╰──╯ ╰──╯",
+		"",
+		"╭──╮",
+		"╰──╯",
+	}
+	if got := c.AsText(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRestyleComponent(t *testing.T) {
+	c := restyleCanvas()
+	c.RestyleComponent(Pos{0, 0}, BorderStyle_None, BorderStyle_Double)
+	want := []string{
+		"╔══╗ ╔══╗",
+		"║  ╠═╣  ║",
+		"╚══╝ ╚══╝",
+		"",
+		"┏━━┓",
+		"┗━━┛",
+	}
+	if got := c.AsText(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Nothing happens without a border under the cursor
+	c.RestyleComponent(Pos{1, 1}, BorderStyle_None, BorderStyle_Light)
+	if got := c.AsText(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,185 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"github.com/asig/termbox-go"
+)
+
+// Screen is what the UI draws on and reads its input from: the terminal,
+// or a MemScreen in tests.
+type Screen interface {
+	Size() (w, h int)
+	SetCell(x, y int, ch rune, fg, bg termbox.Attribute)
+	Cell(x, y int) termbox.Cell
+	SetCursor(x, y int)
+	Cursor() (x, y int)
+	HideCursor()
+	Flush() error
+	PollEvent() termbox.Event
+}
+
+// TermboxScreen is the terminal, as managed by termbox. termbox needs to be
+// initialized before it's used.
+type TermboxScreen struct{}
+
+func (TermboxScreen) Size() (w, h int) {
+	return termbox.Size()
+}
+
+func (TermboxScreen) SetCell(x, y int, ch rune, fg, bg termbox.Attribute) {
+	termbox.SetCell(x, y, ch, fg, bg)
+}
+
+func (TermboxScreen) Cell(x, y int) termbox.Cell {
+	w, h := termbox.Size()
+	if x < 0 || y < 0 || x >= w || y >= h {
+		return termbox.Cell{}
+	}
+	return termbox.CellBuffer()[y*w+x]
+}
+
+func (TermboxScreen) SetCursor(x, y int) {
+	termbox.SetCursor(x, y)
+}
+
+func (TermboxScreen) Cursor() (x, y int) {
+	return termbox.GetCursor()
+}
+
+func (TermboxScreen) HideCursor() {
+	termbox.HideCursor()
+}
+
+func (TermboxScreen) Flush() error {
+	return termbox.Flush()
+}
+
+// PollEvent reads raw input and parses it, so that Alt and Esc are
+// distinguished reliably.
+func (TermboxScreen) PollEvent() termbox.Event {
+	data := make([]byte, 32)
+	ev := termbox.PollRawEvent(data)
+	if ev.Type == termbox.EventRaw {
+		ev = termbox.ParseEvent(data)
+	}
+	return ev
+}
+
+// MemScreen is a Screen in memory. Events are queued with PushEvents and
+// handed out by PollEvent, which panics if there are none left.
+type MemScreen struct {
+	w, h   int
+	cells  []termbox.Cell
+	cx, cy int
+	events []termbox.Event
+
+	// Number of calls to Flush
+	Flushes int
+}
+
+func NewMemScreen(w, h int) *MemScreen {
+	s := &MemScreen{
+		w:     w,
+		h:     h,
+		cells: make([]termbox.Cell, w*h),
+		cx:    -1,
+		cy:    -1,
+	}
+	for i := range s.cells {
+		s.cells[i].Ch = ' '
+	}
+	return s
+}
+
+func (s *MemScreen) Size() (w, h int) {
+	return s.w, s.h
+}
+
+func (s *MemScreen) SetCell(x, y int, ch rune, fg, bg termbox.Attribute) {
+	if x < 0 || y < 0 || x >= s.w || y >= s.h {
+		return
+	}
+	s.cells[y*s.w+x] = termbox.Cell{Ch: ch, Fg: fg, Bg: bg}
+}
+
+func (s *MemScreen) Cell(x, y int) termbox.Cell {
+	if x < 0 || y < 0 || x >= s.w || y >= s.h {
+		return termbox.Cell{}
+	}
+	return s.cells[y*s.w+x]
+}
+
+func (s *MemScreen) SetCursor(x, y int) {
+	s.cx, s.cy = x, y
+}
+
+func (s *MemScreen) Cursor() (x, y int) {
+	return s.cx, s.cy
+}
+
+func (s *MemScreen) HideCursor() {
+	s.cx, s.cy = -1, -1
+}
+
+func (s *MemScreen) Flush() error {
+	s.Flushes++
+	return nil
+}
+
+func (s *MemScreen) PollEvent() termbox.Event {
+	if len(s.events) == 0 {
+		panic("MemScreen: no more events")
+	}
+	ev := s.events[0]
+	s.events = s.events[1:]
+	return ev
+}
+
+// PushEvents queues events for PollEvent.
+func (s *MemScreen) PushEvents(evs ...termbox.Event) {
+	s.events = append(s.events, evs...)
+}
+
+// PushKeys queues key events for special keys.
+func (s *MemScreen) PushKeys(keys ...termbox.Key) {
+	for _, k := range keys {
+		s.events = append(s.events, termbox.Event{Type: termbox.EventKey, Key: k})
+	}
+}
+
+// PushText queues key events for typing text.
+func (s *MemScreen) PushText(text string) {
+	for _, ch := range text {
+		s.events = append(s.events, termbox.Event{Type: termbox.EventKey, Ch: ch})
+	}
+}
+
+// Lines returns the characters on the screen, one string per row.
+func (s *MemScreen) Lines() []string {
+	lines := make([]string, s.h)
+	for y := range lines {
+		row := make([]rune, s.w)
+		for x := range row {
+			row[x] = s.cells[y*s.w+x].Ch
+		}
+		lines[y] = string(row)
+	}
+	return lines
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/asig/termbox-go"
+)
+
+func TestCanvasDraw(t *testing.T) {
+	t.Parallel()
+	s := NewMemScreen(12, 4)
+	c := NewCanvas(0, 0, 12, 4, WithScreen(s))
+	c.SetText([]string{"termdraw"})
+	c.DrawBox(Rect{0, 1, 5, 3}, BorderStyle_Light)
+	c.DrawBox(Rect{2, 1, 5, 3}, BorderStyle_Light)
+	c.SetPos(Pos{3, 0})
+	c.Draw()
+
+	want := []string{
+		"termdraw    ",
+		"┌─┬─┬─┐     ",
+		"│ │ │ │     ",
+		"└─┴─┴─┘     ",
+	}
+	if got := s.Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("screen is\n%q\nwant\n%q", got, want)
+	}
+	if x, y := s.Cursor(); x != 3 || y != 0 {
+		t.Errorf("cursor at %d,%d, want 3,0", x, y)
+	}
+}
+
+func TestChoiceDialog(t *testing.T) {
+	t.Parallel()
+	s := NewMemScreen(40, 10)
+	Puts(s, 0, 0, "background", ColWhite, ColBlack)
+	before := s.Lines()
+
+	s.PushKeys(termbox.KeyArrowDown, termbox.KeyArrowDown, termbox.KeyArrowUp, termbox.KeyEnter)
+	i, ok := ChoiceDialog(s, "Export", []string{"ANSI", "HTML", "SVG"})
+	if !ok || i != 1 {
+		t.Errorf("ChoiceDialog returned %d, %t; want 1, true", i, ok)
+	}
+	if got := s.Lines(); !reflect.DeepEqual(got, before) {
+		t.Errorf("screen not restored:\n%q", got)
+	}
+}
+
+func TestChoiceDialogCancel(t *testing.T) {
+	t.Parallel()
+	s := NewMemScreen(40, 10)
+	s.PushEvents(termbox.Event{Type: termbox.EventKey, Mod: termbox.ModAlt})
+	if _, ok := ChoiceDialog(s, "Export", []string{"ANSI", "HTML"}); ok {
+		t.Errorf("ChoiceDialog not cancelled by Esc")
+	}
+}
+
+func TestFileDialog(t *testing.T) {
+	t.Parallel()
+	s := NewMemScreen(80, 10)
+	s.PushText("drawing.txx")
+	s.PushKeys(termbox.KeyBackspace)
+	s.PushText("t")
+	s.PushKeys(termbox.KeyEnter)
+	name, ok := FileDialog(s, "Save File")
+	if !ok || name != "drawing.txt" {
+		t.Errorf("FileDialog returned %q, %t; want \"drawing.txt\", true", name, ok)
+	}
+}
+
+func TestErrorDialog(t *testing.T) {
+	t.Parallel()
+	s := NewMemScreen(40, 10)
+	before := s.Lines()
+	s.PushText("x")
+	s.PushKeys(termbox.KeyEnter)
+	ErrorDialog(s, "disk full")
+	if got := s.Lines(); !reflect.DeepEqual(got, before) {
+		t.Errorf("screen not restored:\n%q", got)
+	}
+}
+
+func TestTextCard(t *testing.T) {
+	t.Parallel()
+	s := NewMemScreen(12, 4)
+	tc := &TextCard{
+		Fg:      ColYellow,
+		Bg:      ColBrown,
+		Bs:      BorderStyle_Light,
+		Content: []Line{{{"Hi", ColWhite, ColBrown}, {" you", ColYellow, ColBrown}}},
+	}
+	tc.Show(s)
+	want := []string{
+		" ┌────────┐ ",
+		" │ Hi you │ ",
+		" └────────┘ ",
+		"            ",
+	}
+	if got := s.Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("screen is\n%q\nwant\n%q", got, want)
+	}
+}
@@ -0,0 +1,208 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+type Rect struct {
+	X, Y, W, H int
+}
+
+// RectFromCorners returns the smallest rectangle containing both a and b.
+func RectFromCorners(a, b Pos) Rect {
+	return Rect{
+		X: min(a.X, b.X),
+		Y: min(a.Y, b.Y),
+		W: max(a.X, b.X) - min(a.X, b.X) + 1,
+		H: max(a.Y, b.Y) - min(a.Y, b.Y) + 1,
+	}
+}
+
+func (r Rect) Contains(p Pos) bool {
+	return p.X >= r.X && p.X < r.X+r.W && p.Y >= r.Y && p.Y < r.Y+r.H
+}
+
+// Clipboard holds a rectangular block of cells, including their colors and
+// tiles.
+type Clipboard struct {
+	w, h  int
+	cells []cell
+}
+
+func (cb *Clipboard) Size() (w, h int) {
+	return cb.w, cb.h
+}
+
+func (cb *Clipboard) at(x, y int) cell {
+	return cb.cells[y*cb.w+x]
+}
+
+func (c *Canvas) StartSelection() {
+	c.dropFloating()
+	c.selAnchor = c.Pos()
+	c.selActive = true
+}
+
+func (c *Canvas) ClearSelection() {
+	c.dropFloating()
+	c.selActive = false
+}
+
+// SelectionAnchor returns the corner of the selection where it was started.
+func (c *Canvas) SelectionAnchor() Pos {
+	return c.selAnchor
+}
+
+// Selection returns the rectangle spanned by the selection anchor and the
+// cursor, and whether a selection is active at all.
+func (c *Canvas) Selection() (Rect, bool) {
+	if !c.selActive {
+		return Rect{}, false
+	}
+	return RectFromCorners(c.selAnchor, c.Pos()), true
+}
+
+func (c *Canvas) Copy(r Rect) *Clipboard {
+	cb := &Clipboard{
+		w:     r.W,
+		h:     r.H,
+		cells: make([]cell, 0, r.W*r.H),
+	}
+	for y := 0; y < r.H; y++ {
+		for x := 0; x < r.W; x++ {
+			cb.cells = append(cb.cells, c.cellAt(Pos{r.X + x, r.Y + y}))
+		}
+	}
+	return cb
+}
+
+// Cut copies r and blanks it afterwards.
+func (c *Canvas) Cut(r Rect) *Clipboard {
+	cb := c.Copy(r)
+	c.BeginGroup()
+	c.fill(r, c.blank())
+	c.EndGroup()
+	return cb
+}
+
+func (c *Canvas) fill(r Rect, cl cell) {
+	for y := r.Y; y < r.Y+r.H; y++ {
+		for x := r.X; x < r.X+r.W; x++ {
+			c.setCell(Pos{x, y}, cl)
+		}
+	}
+}
+
+// Paste puts the clipboard's content at p. If transparent is set, blank
+// cells in the clipboard leave the canvas untouched, and borders are merged
+// with the borders already on the canvas. Borders leaving the pasted block
+// are joined with the borders next to it.
+func (c *Canvas) Paste(p Pos, cb *Clipboard, transparent bool) {
+	c.BeginGroup()
+	c.paste(p, cb, transparent)
+	c.joinBorders(Rect{p.X, p.Y, cb.w, cb.h})
+	c.EndGroup()
+}
+
+func (c *Canvas) paste(p Pos, cb *Clipboard, transparent bool) {
+	for y := 0; y < cb.h; y++ {
+		for x := 0; x < cb.w; x++ {
+			pos := Pos{p.X + x, p.Y + y}
+			cl := cb.at(x, y)
+			if transparent {
+				if cl.ch == ' ' && cl.tile == 0 {
+					continue
+				}
+				if old := c.cellAt(pos); old.tile != 0 && cl.tile != 0 {
+					cl.tile = old.tile.Merge(cl.tile)
+					if ch := cl.tile.Rune(); ch != ' ' {
+						cl.ch = ch
+					}
+				}
+			}
+			c.setCell(pos, cl)
+		}
+	}
+}
+
+// joinBorders adds the missing counterparts to border arms that leave r and
+// end in a border cell outside of it.
+func (c *Canvas) joinBorders(r Rect) {
+	for y := r.Y; y < r.Y+r.H; y++ {
+		for x := r.X; x < r.X+r.W; x++ {
+			p := Pos{x, y}
+			t := c.Tile(p)
+			for _, d := range allDirections {
+				bs := t.Dir(d)
+				n := p.Step(d)
+				if bs == BorderStyle_None || n.X < 0 || n.Y < 0 || r.Contains(n) {
+					continue
+				}
+				nt := c.Tile(n)
+				if nt != 0 && nt.Dir(d.Inverse()) == BorderStyle_None {
+					c.SetTile(n, nt.WithDir(d.Inverse(), bs))
+				}
+			}
+		}
+	}
+}
+
+// MoveSelection moves the selected block one cell in direction d, restoring
+// whatever the block covered before. The cursor and the selection follow the
+// block. It returns false if the block can't be moved.
+func (c *Canvas) MoveSelection(d Direction) bool {
+	r, ok := c.Selection()
+	if !ok {
+		return false
+	}
+	dst := Pos{r.X, r.Y}.Step(d)
+	if dst.X < 0 || dst.Y < 0 {
+		return false
+	}
+
+	c.BeginGroup()
+	defer c.EndGroup()
+
+	if c.floating == nil {
+		// Lift the block off the canvas
+		c.floating = c.Copy(r)
+		c.under = &Clipboard{w: r.W, h: r.H, cells: make([]cell, r.W*r.H)}
+		for i := range c.under.cells {
+			c.under.cells[i] = c.blank()
+		}
+	}
+	c.paste(Pos{r.X, r.Y}, c.under, false)
+	c.under = c.Copy(Rect{dst.X, dst.Y, r.W, r.H})
+	c.paste(dst, c.floating, false)
+
+	before, after := c.selAnchor, c.selAnchor.Step(d)
+	c.selAnchor = after
+	c.record(func(c *Canvas) { c.selAnchor = before }, func(c *Canvas) { c.selAnchor = after })
+	c.SetPos(c.Pos().Step(d))
+	return true
+}
+
+// EndMove lets go of the block moved with MoveSelection. The next move lifts
+// the selected block off the canvas again.
+func (c *Canvas) EndMove() {
+	c.dropFloating()
+}
+
+func (c *Canvas) dropFloating() {
+	c.floating = nil
+	c.under = nil
+}
@@ -0,0 +1,163 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClipboard(t *testing.T) {
+	box := []string{
+		"┌─┐ ab",
+		"└─┘ cd",
+	}
+	tests := []struct {
+		name string
+		text []string
+		run  func(c *Canvas)
+		want []string
+	}{
+		{
+			"copy and paste",
+			box,
+			func(c *Canvas) { c.Paste(Pos{6, 0}, c.Copy(Rect{0, 0, 3, 2}), false) },
+			[]string{"┌─┐ ab┌─┐", "└─┘ cd└─┘"},
+		},
+		{
+			"cut",
+			box,
+			func(c *Canvas) { c.Cut(Rect{4, 0, 2, 2}) },
+			[]string{"┌─┐", "└─┘"},
+		},
+		{
+			"paste overwrites",
+			box,
+			func(c *Canvas) { c.Paste(Pos{0, 0}, c.Copy(Rect{3, 0, 3, 2}), false) },
+			[]string{" ab ab", " cd cd"},
+		},
+		{
+			"transparent paste skips blanks",
+			box,
+			func(c *Canvas) { c.Paste(Pos{0, 0}, c.Copy(Rect{3, 0, 3, 2}), true) },
+			[]string{"┌ab ab", "└cd cd"},
+		},
+		{
+			"transparent paste merges borders",
+			box,
+			func(c *Canvas) { c.Paste(Pos{2, 0}, c.Copy(Rect{0, 0, 3, 2}), true) },
+			[]string{"┌─┬─┐b", "└─┴─┘d"},
+		},
+		{
+			"pasted arms join borders",
+			[]string{"───  │", "     │"},
+			func(c *Canvas) { c.Paste(Pos{3, 1}, c.Copy(Rect{0, 0, 2, 1}), false) },
+			[]string{"───  │", "   ──┤"},
+		},
+	}
+	for _, tc := range tests {
+		c := NewCanvas(0, 0, 20, 5)
+		c.SetText(tc.text)
+		tc.run(c)
+		if got := trimmedText(c); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+		// Every command is a single undo step
+		c.Undo()
+		if got := trimmedText(c); !reflect.DeepEqual(got, tc.text) {
+			t.Errorf("%s: after undo, got %q", tc.name, got)
+		}
+	}
+}
+
+func TestMoveSelection(t *testing.T) {
+	c := NewCanvas(0, 0, 20, 5)
+	c.SetText([]string{
+		"┌─┐ ab",
+		"└─┘ cd",
+	})
+	c.StartSelection()
+	c.SetPos(Pos{2, 1})
+	if c.MoveSelection(DirLeft) {
+		t.Errorf("block moved beyond the left edge")
+	}
+	c.MoveSelection(DirRight)
+	c.MoveSelection(DirRight)
+	want := []string{
+		"  ┌─┐b",
+		"  └─┘d",
+	}
+	if got := trimmedText(c); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// What the block covered comes back when it moves on
+	c.MoveSelection(DirDown)
+	want = []string{
+		"    ab",
+		"  ┌─┐d",
+		"  └─┘",
+	}
+	if got := trimmedText(c); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if r, _ := c.Selection(); r != (Rect{2, 1, 3, 2}) {
+		t.Errorf("selection is %v, want 2,1 3x2", r)
+	}
+	if got := c.Pos(); got != (Pos{4, 2}) {
+		t.Errorf("cursor at %v, want 4,2", got)
+	}
+}
+
+func TestMoveSelectionUndo(t *testing.T) {
+	c := NewCanvas(0, 0, 20, 5)
+	c.SetText([]string{"AB.Z"})
+	c.StartSelection()
+	c.SetPos(Pos{1, 0})
+	c.BeginGroup()
+	c.MoveSelection(DirRight)
+	c.EndGroup()
+
+	c.Undo()
+	if got := trimmedText(c); !reflect.DeepEqual(got, []string{"AB.Z"}) {
+		t.Errorf("after undo, got %q", got)
+	}
+	if r, _ := c.Selection(); r != (Rect{0, 0, 2, 1}) {
+		t.Errorf("after undo, selection is %v, want 0,0 2x1", r)
+	}
+
+	// The next move lifts the edited block, not the one moved before
+	c.SetRune(Pos{0, 0}, 'x')
+	c.SetPos(Pos{1, 0})
+	c.MoveSelection(DirRight)
+	if got := trimmedText(c); !reflect.DeepEqual(got, []string{" xBZ"}) {
+		t.Errorf("after moving again, got %q", got)
+	}
+	c.EndMove()
+	c.MoveSelection(DirRight)
+	if got := trimmedText(c); !reflect.DeepEqual(got, []string{"  xB"}) {
+		t.Errorf("after ending the move, got %q", got)
+	}
+
+	c.Undo()
+	c.Redo()
+	if r, _ := c.Selection(); r != (Rect{2, 0, 2, 1}) {
+		t.Errorf("after redo, selection is %v, want 2,0 2x1", r)
+	}
+}
@@ -0,0 +1,229 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+// Stroke draws a border segment from p to its neighbour in direction d,
+// merging it with the borders already there.
+func (c *Canvas) Stroke(p Pos, d Direction, bs BorderStyle) {
+	n := p.Step(d)
+	if n.X < 0 || n.Y < 0 {
+		return
+	}
+	// Leaving the current tile in direction d...
+	c.SetTile(p, c.strokeTile(p, bs).WithDir(d, bs))
+	// ... and entering the new one from the inverse direction
+	c.SetTile(n, c.strokeTile(n, bs).WithDir(d.Inverse(), bs))
+}
+
+// strokeTile returns the tile at p that a stroke in style bs merges with.
+// ASCII strokes join the boxes of plain ASCII text, e.g. of diagrams loaded
+// from a text file.
+func (c *Canvas) strokeTile(p Pos, bs BorderStyle) Tile {
+	if bs == BorderStyle_ASCII && c.asciiChar(p) != 0 {
+		return c.asciiTile(p)
+	}
+	return c.Tile(p)
+}
+
+// Erase removes the border segment from p to its neighbour in direction d,
+// leaving the other arms of both cells and their colors alone.
+func (c *Canvas) Erase(p Pos, d Direction) {
+	n := p.Step(d)
+	if n.X < 0 || n.Y < 0 {
+		return
+	}
+	if t := c.Tile(p); t.Dir(d) != BorderStyle_None {
+		c.replaceTile(p, t.WithDir(d, BorderStyle_None))
+	}
+	if t := c.Tile(n); t.Dir(d.Inverse()) != BorderStyle_None {
+		c.replaceTile(n, t.WithDir(d.Inverse(), BorderStyle_None))
+	}
+}
+
+// strokeN draws n segments starting at p in direction d, and returns the
+// end position.
+func (c *Canvas) strokeN(p Pos, d Direction, n int, bs BorderStyle) Pos {
+	for i := 0; i < n; i++ {
+		c.Stroke(p, d, bs)
+		p = p.Step(d)
+	}
+	return p
+}
+
+// DrawBox draws the outline of r, merging it with the borders already on
+// the canvas.
+func (c *Canvas) DrawBox(r Rect, bs BorderStyle) {
+	c.BeginGroup()
+	defer c.EndGroup()
+
+	p := Pos{r.X, r.Y}
+	p = c.strokeN(p, DirRight, r.W-1, bs)
+	p = c.strokeN(p, DirDown, r.H-1, bs)
+	p = c.strokeN(p, DirLeft, r.W-1, bs)
+	c.strokeN(p, DirUp, r.H-1, bs)
+}
+
+// Route defines how DrawConnector gets from one point to the other.
+type Route uint8
+
+const (
+	// A single horizontal or vertical line. If the points are not aligned,
+	// the end point is moved onto the dominant axis.
+	RouteStraight Route = iota
+	// L-shaped, horizontal first
+	RouteHorizontalFirst
+	// L-shaped, vertical first
+	RouteVerticalFirst
+	// Z-shaped, bending twice halfway between the points
+	RouteZHorizontal
+	RouteZVertical
+
+	Route_Max = RouteZVertical
+)
+
+// Cap is the decoration at the end of a connector.
+type Cap uint8
+
+const (
+	CapNone Cap = iota
+	CapTriangle
+	CapArrow
+	CapASCII
+	CapDot
+
+	Cap_Max = CapDot
+)
+
+var (
+	routeNames = map[Route]string{
+		RouteStraight:        "Straight",
+		RouteHorizontalFirst: "L horizontal",
+		RouteVerticalFirst:   "L vertical",
+		RouteZHorizontal:     "Z horizontal",
+		RouteZVertical:       "Z vertical",
+	}
+
+	// Cap runes, indexed by the direction the cap points to
+	capRunes = map[Cap]map[Direction]rune{
+		CapTriangle: {DirUp: '▲', DirDown: '▼', DirLeft: '◀', DirRight: '▶'},
+		CapArrow:    {DirUp: '↑', DirDown: '↓', DirLeft: '←', DirRight: '→'},
+		CapASCII:    {DirUp: '^', DirDown: 'v', DirLeft: '<', DirRight: '>'},
+		CapDot:      {DirUp: '●', DirDown: '●', DirLeft: '●', DirRight: '●'},
+	}
+)
+
+func (r Route) Next() Route {
+	next := r + 1
+	if next > Route_Max {
+		next = 0
+	}
+	return next
+}
+
+func (r Route) String() string {
+	return routeNames[r]
+}
+
+func (c Cap) Next() Cap {
+	next := c + 1
+	if next > Cap_Max {
+		next = 0
+	}
+	return next
+}
+
+// Rune returns the cap's rune when pointing to direction d, or ' ' for
+// CapNone.
+func (c Cap) Rune(d Direction) rune {
+	if r, ok := capRunes[c][d]; ok {
+		return r
+	}
+	return ' '
+}
+
+// waypoints returns the corners of the path from a to b.
+func (r Route) waypoints(a, b Pos) []Pos {
+	switch r {
+	case RouteStraight:
+		if abs(b.X-a.X) >= abs(b.Y-a.Y) {
+			return []Pos{a, {b.X, a.Y}}
+		}
+		return []Pos{a, {a.X, b.Y}}
+	case RouteHorizontalFirst:
+		return []Pos{a, {b.X, a.Y}, b}
+	case RouteVerticalFirst:
+		return []Pos{a, {a.X, b.Y}, b}
+	case RouteZHorizontal:
+		mx := (a.X + b.X) / 2
+		return []Pos{a, {mx, a.Y}, {mx, b.Y}, b}
+	case RouteZVertical:
+		my := (a.Y + b.Y) / 2
+		return []Pos{a, {a.X, my}, {b.X, my}, b}
+	}
+	panic("Bad Route!")
+}
+
+// direction returns the direction from a to b, which must be on a
+// horizontal or vertical line.
+func direction(a, b Pos) Direction {
+	switch {
+	case b.X > a.X:
+		return DirRight
+	case b.X < a.X:
+		return DirLeft
+	case b.Y > a.Y:
+		return DirDown
+	default:
+		return DirUp
+	}
+}
+
+// DrawConnector draws a line from a to b along the given route, merging it
+// with the borders already on the canvas. The start and end points are
+// decorated with the given caps.
+func (c *Canvas) DrawConnector(a, b Pos, route Route, bs BorderStyle, startCap, endCap Cap) {
+	c.BeginGroup()
+	defer c.EndGroup()
+
+	var first, last Direction
+	drawn := false
+	pts := route.waypoints(a, b)
+	for i := 1; i < len(pts); i++ {
+		from, to := pts[i-1], pts[i]
+		if from == to {
+			continue
+		}
+		d := direction(from, to)
+		if !drawn {
+			first = d
+			drawn = true
+		}
+		last = d
+		c.strokeN(from, d, abs(to.X-from.X)+abs(to.Y-from.Y), bs)
+	}
+	if !drawn {
+		return
+	}
+	if startCap != CapNone {
+		c.SetRune(pts[0], startCap.Rune(first.Inverse()))
+	}
+	if endCap != CapNone {
+		c.SetRune(pts[len(pts)-1], endCap.Rune(last))
+	}
+}
@@ -0,0 +1,164 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDrawBox(t *testing.T) {
+	box := []string{
+		"┌──┐",
+		"│  │",
+		"└──┘",
+	}
+	tests := []struct {
+		name string
+		text []string
+		r    Rect
+		bs   BorderStyle
+		want []string
+	}{
+		{
+			"empty canvas",
+			nil,
+			Rect{0, 0, 4, 3},
+			BorderStyle_Light,
+			box,
+		},
+		{
+			"shared edge",
+			box,
+			Rect{3, 0, 3, 3},
+			BorderStyle_Light,
+			[]string{"┌──┬─┐", "│  │ │", "└──┴─┘"},
+		},
+		{
+			"overlapping",
+			box,
+			Rect{2, 1, 4, 3},
+			BorderStyle_Light,
+			[]string{"┌──┐", "│ ┌┼─┐", "└─┼┘ │", "  └──┘"},
+		},
+		{
+			"other style",
+			box,
+			Rect{0, 0, 4, 3},
+			BorderStyle_Heavy,
+			[]string{"┏━━┓", "┃  ┃", "┗━━┛"},
+		},
+		{
+			// There is no glyph for light and double arms on the same axis
+			"junction of two styles",
+			box,
+			Rect{3, 0, 3, 3},
+			BorderStyle_Double,
+			[]string{"┌──┬═╗", "│  ║ ║", "└──┴═╝"},
+		},
+	}
+	for _, tc := range tests {
+		c := NewCanvas(0, 0, 20, 5)
+		c.SetText(tc.text)
+		c.DrawBox(tc.r, tc.bs)
+		if got := trimmedText(c); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+		c.Undo()
+		if got := trimmedText(c); !reflect.DeepEqual(got, tc.text) {
+			t.Errorf("%s: box not undone in one step, got %q", tc.name, got)
+		}
+	}
+}
+
+func TestDrawConnector(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       []string
+		a, b       Pos
+		route      Route
+		start, end Cap
+		want       []string
+	}{
+		// Without caps, lines end in half a cell
+		{
+			"straight, snapped to the horizontal",
+			nil, Pos{0, 0}, Pos{4, 1}, RouteStraight, CapNone, CapNone,
+			[]string{"╶───╴"},
+		},
+		{
+			"straight, snapped to the vertical",
+			nil, Pos{1, 0}, Pos{0, 3}, RouteStraight, CapNone, CapNone,
+			[]string{" ╷", " │", " │", " ╵"},
+		},
+		{
+			"L horizontal first",
+			nil, Pos{0, 0}, Pos{3, 2}, RouteHorizontalFirst, CapNone, CapNone,
+			[]string{"╶──┐", "   │", "   ╵"},
+		},
+		{
+			"L vertical first",
+			nil, Pos{0, 0}, Pos{3, 2}, RouteVerticalFirst, CapNone, CapNone,
+			[]string{"╷", "│", "└──╴"},
+		},
+		{
+			"Z horizontal",
+			nil, Pos{0, 0}, Pos{4, 2}, RouteZHorizontal, CapNone, CapNone,
+			[]string{"╶─┐", "  │", "  └─╴"},
+		},
+		{
+			"Z vertical",
+			nil, Pos{0, 0}, Pos{2, 4}, RouteZVertical, CapNone, CapNone,
+			[]string{"╷", "│", "└─┐", "  │", "  ╵"},
+		},
+		{
+			"caps point away from the line",
+			nil, Pos{0, 0}, Pos{4, 0}, RouteStraight, CapArrow, CapArrow,
+			[]string{"←───→"},
+		},
+		{
+			"end cap follows the last segment",
+			nil, Pos{0, 0}, Pos{3, 2}, RouteHorizontalFirst, CapDot, CapTriangle,
+			[]string{"●──┐", "   │", "   ▼"},
+		},
+		{
+			"right to left",
+			nil, Pos{4, 0}, Pos{0, 0}, RouteStraight, CapNone, CapASCII,
+			[]string{"<───╴"},
+		},
+		{
+			"crossing a border",
+			[]string{"  │", "  │", "  │"}, Pos{0, 1}, Pos{4, 1}, RouteStraight, CapNone, CapNone,
+			[]string{"  │", "╶─┼─╴", "  │"},
+		},
+		{
+			"zero length",
+			nil, Pos{2, 2}, Pos{2, 2}, RouteZVertical, CapArrow, CapArrow,
+			nil,
+		},
+	}
+	for _, tc := range tests {
+		c := NewCanvas(0, 0, 20, 5)
+		c.SetText(tc.text)
+		c.DrawConnector(tc.a, tc.b, tc.route, BorderStyle_Light, tc.start, tc.end)
+		if got := trimmedText(c); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/asig/termbox-go"
+)
+
+const (
+	svgCellW    = 10
+	svgCellH    = 20
+	svgFont     = 16
+	svgBaseline = 15
+)
+
+var svgMetrics = tileMetrics{
+	w: svgCellW, h: svgCellH,
+	cx: svgCellW / 2, cy: svgCellH / 2,
+	light: 1.2, heavy: 3, double: 1,
+	gap: 2,
+}
+
+func svgNum(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
+
+// svgPath returns the path data of a stroke in a cell at (x, y).
+func svgPath(s tileStroke, x, y float64) string {
+	x0, y0, x1, y1 := svgNum(x+s.x0), svgNum(y+s.y0), svgNum(x+s.x1), svgNum(y+s.y1)
+	if !s.arc {
+		return fmt.Sprintf("M%s %sL%s %s", x0, y0, x1, y1)
+	}
+	// In SVG's coordinate system, a positive cross product means clockwise
+	sweep := 0
+	if (s.x0-s.ax)*(s.y1-s.ay)-(s.y0-s.ay)*(s.x1-s.ax) > 0 {
+		sweep = 1
+	}
+	r := svgNum(s.r)
+	return fmt.Sprintf("M%s %sA%s %s 0 0 %d %s %s", x0, y0, r, r, sweep, x1, y1)
+}
+
+// svgTextAttrs returns the SVG attributes for the text attributes attrs.
+func svgTextAttrs(attrs termbox.Attribute) string {
+	var sb strings.Builder
+	if attrs&termbox.AttrBold != 0 {
+		sb.WriteString(` font-weight="bold"`)
+	}
+	if attrs&termbox.AttrCursive != 0 {
+		sb.WriteString(` font-style="italic"`)
+	}
+	if attrs&termbox.AttrUnderline != 0 {
+		sb.WriteString(` text-decoration="underline"`)
+	}
+	if attrs&termbox.AttrDim != 0 {
+		sb.WriteString(` opacity="0.6"`)
+	}
+	return sb.String()
+}
+
+// ExportSVG writes the canvas as an SVG image. Cells with tiles are drawn
+// as lines, so that borders join without gaps regardless of the font;
+// everything else is text with every character stretched to the cell
+// width.
+func (c *Canvas) ExportSVG(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	rows := c.trimmedRows()
+	width, height := rowsExtent(rows)
+
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n",
+		width*svgCellW, height*svgCellH, width*svgCellW, height*svgCellH)
+	fmt.Fprintf(bw, "<rect width=\"100%%\" height=\"100%%\" fill=\"%s\"/>\n", colorHex(c.bg))
+
+	// Backgrounds
+	for y, row := range rows {
+		for start := 0; start < len(row); {
+			_, bg, _ := resolveColors(row[start].fg, row[start].bg)
+			end := start + 1
+			for end < len(row) {
+				if _, b, _ := resolveColors(row[end].fg, row[end].bg); b != bg {
+					break
+				}
+				end++
+			}
+			if bg != c.bg {
+				fmt.Fprintf(bw, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\"/>\n",
+					start*svgCellW, y*svgCellH, (end-start)*svgCellW, svgCellH, colorHex(bg))
+			}
+			start = end
+		}
+	}
+
+	// Text
+	fmt.Fprintf(bw, "<g font-family=\"monospace\" font-size=\"%d\" xml:space=\"preserve\">\n", svgFont)
+	isText := func(cl cell) bool { return cl.tile == 0 && cl.ch != ' ' }
+	for y, row := range rows {
+		for start := 0; start < len(row); {
+			if !isText(row[start]) {
+				start++
+				continue
+			}
+			fg, _, attrs := resolveColors(row[start].fg, row[start].bg)
+			end := start + 1
+			for end < len(row) && isText(row[end]) && row[end].fg == row[start].fg && row[end].bg == row[start].bg {
+				end++
+			}
+			var text strings.Builder
+			for _, cl := range row[start:end] {
+				text.WriteRune(cl.ch)
+			}
+			fmt.Fprintf(bw, "<text x=\"%d\" y=\"%d\" textLength=\"%d\" lengthAdjust=\"spacingAndGlyphs\" fill=\"%s\"%s>%s</text>\n",
+				start*svgCellW, y*svgCellH+svgBaseline, (end-start)*svgCellW, colorHex(fg), svgTextAttrs(attrs),
+				html.EscapeString(text.String()))
+			start = end
+		}
+	}
+	bw.WriteString("</g>\n")
+
+	// Borders
+	bw.WriteString("<g fill=\"none\" stroke-linecap=\"butt\">\n")
+	for y, row := range rows {
+		for x, cl := range row {
+			if cl.tile == 0 {
+				continue
+			}
+			fg, _, _ := resolveColors(cl.fg, cl.bg)
+			for _, s := range cl.tile.strokes(svgMetrics) {
+				fmt.Fprintf(bw, "<path d=\"%s\" stroke=\"%s\" stroke-width=\"%s\"/>\n",
+					svgPath(s, float64(x*svgCellW), float64(y*svgCellH)), colorHex(fg), svgNum(s.width))
+			}
+		}
+	}
+	bw.WriteString("</g>\n</svg>\n")
+	return bw.Flush()
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/asig/termbox-go"
+)
+
+type testSVG struct {
+	Width  int `xml:"width,attr"`
+	Height int `xml:"height,attr"`
+	Groups []struct {
+		Texts []struct {
+			X    int    `xml:"x,attr"`
+			Text string `xml:",chardata"`
+		} `xml:"text"`
+		Paths []struct {
+			D string `xml:"d,attr"`
+		} `xml:"path"`
+	} `xml:"g"`
+}
+
+func TestExportSVG(t *testing.T) {
+	c := NewCanvas(0, 0, 20, 5)
+	c.SetText([]string{"", ` a<b&"c"'`})
+	c.DrawBox(Rect{0, 0, 12, 3}, BorderStyle_Rounded)
+	c.SetColors(ColRed|termbox.AttrBold, ColBlue)
+	c.SetRune(Pos{13, 1}, '>')
+
+	var buf bytes.Buffer
+	if err := c.ExportSVG(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var svg testSVG
+	if err := xml.Unmarshal(buf.Bytes(), &svg); err != nil {
+		t.Fatalf("invalid SVG: %v\n%s", err, buf.String())
+	}
+	if svg.Width != 14*svgCellW || svg.Height != 3*svgCellH {
+		t.Errorf("size is %dx%d, want %dx%d", svg.Width, svg.Height, 14*svgCellW, 3*svgCellH)
+	}
+	if len(svg.Groups) != 2 {
+		t.Fatalf("got %d groups, want 2", len(svg.Groups))
+	}
+	texts := svg.Groups[0].Texts
+	if len(texts) != 2 || texts[0].Text != `a<b&"c"'` || texts[0].X != svgCellW || texts[1].Text != ">" {
+		t.Errorf("texts are %+v", texts)
+	}
+	// 4 corners with an arc each, and 2 arms for each of the 26 lines
+	if got := len(svg.Groups[1].Paths); got != 4+2*26 {
+		t.Errorf("got %d paths, want %d", got, 4+2*26)
+	}
+}
+
+func TestExportSVGTrimmed(t *testing.T) {
+	c := NewCanvas(0, 0, 20, 5)
+	c.SetText([]string{"ab   ", "c", "", "    "})
+	var buf bytes.Buffer
+	if err := c.ExportSVG(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var svg testSVG
+	if err := xml.Unmarshal(buf.Bytes(), &svg); err != nil {
+		t.Fatalf("invalid SVG: %v\n%s", err, buf.String())
+	}
+	if svg.Width != 2*svgCellW || svg.Height != 2*svgCellH {
+		t.Errorf("size is %dx%d, want %dx%d", svg.Width, svg.Height, 2*svgCellW, 2*svgCellH)
+	}
+}
@@ -43,7 +43,7 @@ type TextCard struct {
 	Content []Line
 }
 
-func (t *TextCard) Show() {
+func (t *TextCard) Show(screen Screen) {
 	contentWidth := 0
 	for _, s := range t.Content {
 		w := s.Width()
@@ -52,20 +52,20 @@ func (t *TextCard) Show() {
 		}
 	}
 
-	termW, termH := termbox.Size()
+	termW, termH := screen.Size()
 	w := contentWidth + 4
 	h := len(t.Content) + 2
 	x := (termW - w) / 2
 	y := (termH - h) / 2
 
-	FillBox(x, y, w, h, t.Fg, t.Bg, t.Bs)
+	FillBox(screen, x, y, w, h, t.Fg, t.Bg, t.Bs)
 	for i, l := range t.Content {
 		px := x + 2
 		for _, s := range l {
-			Puts(px, y+1+i, s.S, s.Fg, s.Bg)
+			Puts(screen, px, y+1+i, s.S, s.Fg, s.Bg)
 			px += len(s.S)
 		}
 	}
-	termbox.Flush()
+	screen.Flush()
 }
 
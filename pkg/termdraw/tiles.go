@@ -22,380 +22,250 @@ import (
 	"fmt"
 )
 
+//go:generate go run ../../tools/gentiles -diagonals -o tiles_gen.go
+
 type (
-	Tile uint32
+	Tile uint64
 )
 
 var (
-	// generated with gentiles.go
-	tileToRune = map[Tile]rune{
-		newTile(" rh "): '┕',
-		newTile(" lh "): '┕',
-		newTile(" rdl"): '╞',
-		newTile(" ldr"): '╞',
-		newTile(" rdr"): '╞',
-		newTile(" ldl"): '╞',
-		newTile("h ll"): '┭',
-		newTile("h rl"): '┭',
-		newTile("h lr"): '┭',
-		newTile("h rr"): '┭',
-		newTile("r hl"): '┮',
-		newTile("l hr"): '┮',
-		newTile("r hr"): '┮',
-		newTile("l hl"): '┮',
-		newTile("d dd"): '╦',
-		newTile("h   "): '╸',
-		newTile("l  h"): '┒',
-		newTile("r  h"): '┒',
-		newTile("d dl"): '╤',
-		newTile("d dr"): '╤',
-		newTile("rhrl"): '╀',
-		newTile("lhlr"): '╀',
-		newTile("rhlr"): '╀',
-		newTile("lhrr"): '╀',
-		newTile("rhrr"): '╀',
-		newTile("lhll"): '╀',
-		newTile("rhll"): '╀',
-		newTile("lhrl"): '╀',
-		newTile("hhrr"): '╃',
-		newTile("hhll"): '╃',
-		newTile("hhrl"): '╃',
-		newTile("hhlr"): '╃',
-		newTile("lhhl"): '╄',
-		newTile("rhhl"): '╄',
-		newTile("lhhr"): '╄',
-		newTile("rhhr"): '╄',
-		newTile(" ld "): '╘',
-		newTile(" rd "): '╘',
-		newTile("  lh"): '┎',
-		newTile("  rh"): '┎',
-		newTile(" hh "): '┗',
-		newTile("ll  "): '┘',
-		newTile("rl  "): '┘',
-		newTile("lr  "): '┘',
-		newTile("hhhh"): '╋',
-		newTile("l h "): '╼',
-		newTile("r h "): '╼',
-		newTile(" l l"): '│',
-		newTile(" r l"): '│',
-		newTile(" l r"): '│',
-		newTile(" r r"): '│',
-		newTile("r rr"): '┬',
-		newTile("l ll"): '┬',
-		newTile("r ll"): '┬',
-		newTile("l rl"): '┬',
-		newTile("r rl"): '┬',
-		newTile("l lr"): '┬',
-		newTile("r lr"): '┬',
-		newTile("l rr"): '┬',
-		newTile(" rlr"): '├',
-		newTile(" lrr"): '├',
-		newTile(" rrr"): '├',
-		newTile(" lll"): '├',
-		newTile(" rll"): '├',
-		newTile(" lrl"): '├',
-		newTile(" rrl"): '├',
-		newTile(" llr"): '├',
-		newTile("hhlh"): '╉',
-		newTile("hhrh"): '╉',
-		newTile("dddd"): '╬',
-		newTile("hl l"): '┥',
-		newTile("hr l"): '┥',
-		newTile("hl r"): '┥',
-		newTile("hr r"): '┥',
-		newTile("lhr "): '┸',
-		newTile("rhr "): '┸',
-		newTile("lhl "): '┸',
-		newTile("rhl "): '┸',
-		newTile("hllh"): '╅',
-		newTile("hrlh"): '╅',
-		newTile("hlrh"): '╅',
-		newTile("hrrh"): '╅',
-		newTile("ld  "): '╜',
-		newTile("rd  "): '╜',
-		newTile("dldl"): '╪',
-		newTile("drdl"): '╪',
-		newTile("dldr"): '╪',
-		newTile("drdr"): '╪',
-		newTile(" rhh"): '┢',
-		newTile(" lhh"): '┢',
-		newTile("d  l"): '╕',
-		newTile("d  r"): '╕',
-		newTile("   l"): '╷',
-		newTile("   r"): '╷',
-		newTile("hh h"): '┫',
-		newTile("h hh"): '┳',
-		newTile("ldl "): '╨',
-		newTile("rdl "): '╨',
-		newTile("ldr "): '╨',
-		newTile("rdr "): '╨',
-		newTile("l   "): '╴',
-		newTile("r   "): '╴',
-		newTile("lrll"): '┼',
-		newTile("rrlr"): '┼',
-		newTile("llrl"): '┼',
-		newTile("rlrl"): '┼',
-		newTile("lrlr"): '┼',
-		newTile("llrr"): '┼',
-		newTile("rlrr"): '┼',
-		newTile("llll"): '┼',
-		newTile("rlll"): '┼',
-		newTile("rrll"): '┼',
-		newTile("lrrl"): '┼',
-		newTile("lllr"): '┼',
-		newTile("rrrr"): '┼',
-		newTile("rrrl"): '┼',
-		newTile("rllr"): '┼',
-		newTile("lrrr"): '┼',
-		newTile("  dl"): '╒',
-		newTile("  dr"): '╒',
-		newTile("   h"): '╻',
-		newTile(" lhl"): '┝',
-		newTile(" rhl"): '┝',
-		newTile(" lhr"): '┝',
-		newTile(" rhr"): '┝',
-		newTile("ll r"): '┤',
-		newTile("rl r"): '┤',
-		newTile("lr r"): '┤',
-		newTile("rr r"): '┤',
-		newTile("ll l"): '┤',
-		newTile("rl l"): '┤',
-		newTile("lr l"): '┤',
-		newTile("rr l"): '┤',
-		newTile("hlhl"): '┿',
-		newTile("hrhl"): '┿',
-		newTile("hlhr"): '┿',
-		newTile("hrhr"): '┿',
-		newTile("rhrh"): '╂',
-		newTile("lhlh"): '╂',
-		newTile("rhlh"): '╂',
-		newTile("lhrh"): '╂',
-		newTile("h  l"): '┑',
-		newTile("h  r"): '┑',
-		newTile("rlr "): '┴',
-		newTile("lrr "): '┴',
-		newTile("rrr "): '┴',
-		newTile("lll "): '┴',
-		newTile("rll "): '┴',
-		newTile("lrl "): '┴',
-		newTile("rrl "): '┴',
-		newTile("llr "): '┴',
-		newTile("hll "): '┵',
-		newTile("hrl "): '┵',
-		newTile("hlr "): '┵',
-		newTile("hrr "): '┵',
-		newTile("d  d"): '╗',
-		newTile(" rr "): '╰',
-		newTile("hh  "): '┛',
-		newTile(" hll"): '┞',
-		newTile(" hrl"): '┞',
-		newTile(" hlr"): '┞',
-		newTile(" hrr"): '┞',
-		newTile("hh l"): '┩',
-		newTile("hh r"): '┩',
-		newTile("  l "): '╶',
-		newTile("  r "): '╶',
-		newTile("  hl"): '┍',
-		newTile("  hr"): '┍',
-		newTile("hrlr"): '┽',
-		newTile("hlrr"): '┽',
-		newTile("hrrr"): '┽',
-		newTile("hlll"): '┽',
-		newTile("hrll"): '┽',
-		newTile("hlrl"): '┽',
-		newTile("hrrl"): '┽',
-		newTile("hllr"): '┽',
-		newTile("ldld"): '╫',
-		newTile("rdld"): '╫',
-		newTile("ldrd"): '╫',
-		newTile("rdrd"): '╫',
-		newTile("  hh"): '┏',
-		newTile("lh h"): '┨',
-		newTile("rh h"): '┨',
-		newTile("r  r"): '╮',
-		newTile(" l  "): '╵',
-		newTile(" r  "): '╵',
-		newTile(" hhl"): '┡',
-		newTile(" hhr"): '┡',
-		newTile("hhl "): '┹',
-		newTile("hhr "): '┹',
-		newTile("lrhl"): '┾',
-		newTile("rrhl"): '┾',
-		newTile("llhr"): '┾',
-		newTile("rlhr"): '┾',
-		newTile("lrhr"): '┾',
-		newTile("rrhr"): '┾',
-		newTile("llhl"): '┾',
-		newTile("rlhl"): '┾',
-		newTile("l  d"): '╖',
-		newTile("r  d"): '╖',
-		newTile("rr  "): '╯',
-		newTile(" ll "): '└',
-		newTile(" rl "): '└',
-		newTile(" lr "): '└',
-		newTile(" dd "): '╚',
-		newTile("lhh "): '┺',
-		newTile("rhh "): '┺',
-		newTile(" dld"): '╟',
-		newTile(" drd"): '╟',
-		newTile("dl l"): '╡',
-		newTile("dr l"): '╡',
-		newTile("dl r"): '╡',
-		newTile("dr r"): '╡',
-		newTile("drd "): '╧',
-		newTile("dld "): '╧',
-		newTile("hl  "): '┙',
-		newTile("hr  "): '┙',
-		newTile("hlh "): '┷',
-		newTile("hrh "): '┷',
-		newTile("lrlh"): '╁',
-		newTile("rrlh"): '╁',
-		newTile("llrh"): '╁',
-		newTile("rlrh"): '╁',
-		newTile("lrrh"): '╁',
-		newTile("rrrh"): '╁',
-		newTile("lllh"): '╁',
-		newTile("rllh"): '╁',
-		newTile("r ld"): '╥',
-		newTile("l rd"): '╥',
-		newTile("r rd"): '╥',
-		newTile("l ld"): '╥',
-		newTile("rl h"): '┧',
-		newTile("lr h"): '┧',
-		newTile("rr h"): '┧',
-		newTile("ll h"): '┧',
-		newTile("hl h"): '┪',
-		newTile("hr h"): '┪',
-		newTile("h lh"): '┱',
-		newTile("h rh"): '┱',
-		newTile("d d "): '═',
-		newTile("dd  "): '╝',
-		newTile("hhh "): '┻',
-		newTile("rrhh"): '╆',
-		newTile("llhh"): '╆',
-		newTile("rlhh"): '╆',
-		newTile("lrhh"): '╆',
-		newTile("  dd"): '╔',
-		newTile(" h l"): '╿',
-		newTile(" h r"): '╿',
-		newTile("l  l"): '┐',
-		newTile("r  l"): '┐',
-		newTile("l  r"): '┐',
-		newTile(" llh"): '┟',
-		newTile(" rlh"): '┟',
-		newTile(" lrh"): '┟',
-		newTile(" rrh"): '┟',
-		newTile(" hhh"): '┣',
-		newTile("ld d"): '╢',
-		newTile("rd d"): '╢',
-		newTile("  h "): '╺',
-		newTile(" h  "): '╹',
-		newTile("ddd "): '╩',
-		newTile("  ll"): '┌',
-		newTile("  rl"): '┌',
-		newTile("  lr"): '┌',
-		newTile(" hrh"): '┠',
-		newTile(" hlh"): '┠',
-		newTile(" d d"): '║',
-		newTile("  ld"): '╓',
-		newTile("  rd"): '╓',
-		newTile(" ddd"): '╠',
-		newTile("h  h"): '┓',
-		newTile("lh  "): '┚',
-		newTile("rh  "): '┚',
-		newTile("l rh"): '┰',
-		newTile("r rh"): '┰',
-		newTile("l lh"): '┰',
-		newTile("r lh"): '┰',
-		newTile("dd d"): '╣',
-		newTile("  rr"): '╭',
-		newTile("l l "): '─',
-		newTile("r l "): '─',
-		newTile("l r "): '─',
-		newTile("r r "): '─',
-		newTile(" h h"): '┃',
-		newTile("hlhh"): '╈',
-		newTile("hrhh"): '╈',
-		newTile("lhhh"): '╊',
-		newTile("rhhh"): '╊',
-		newTile(" dl "): '╙',
-		newTile(" dr "): '╙',
-		newTile("rh r"): '┦',
-		newTile("lh l"): '┦',
-		newTile("rh l"): '┦',
-		newTile("lh r"): '┦',
-		newTile("h r "): '╾',
-		newTile("h l "): '╾',
-		newTile("hhhl"): '╇',
-		newTile("hhhr"): '╇',
-		newTile("dl  "): '╛',
-		newTile("dr  "): '╛',
-		newTile(" l h"): '╽',
-		newTile(" r h"): '╽',
-		newTile("h h "): '━',
-		newTile(" hr "): '┖',
-		newTile(" hl "): '┖',
-		newTile("h hr"): '┯',
-		newTile("h hl"): '┯',
-		newTile("l hh"): '┲',
-		newTile("r hh"): '┲',
-		newTile("llh "): '┶',
-		newTile("rlh "): '┶',
-		newTile("lrh "): '┶',
-		newTile("rrh "): '┶',
+	// Characters used for the border styles in tile patterns
+	borderStyleChars = map[BorderStyle]byte{
+		BorderStyle_None:    ' ',
+		BorderStyle_Rounded: 'r',
+		BorderStyle_Light:   'l',
+		BorderStyle_Heavy:   'h',
+		BorderStyle_Double:  'd',
+
+		BorderStyle_LightDoubleDash:    'b',
+		BorderStyle_HeavyDoubleDash:    'B',
+		BorderStyle_LightTripleDash:    't',
+		BorderStyle_HeavyTripleDash:    'T',
+		BorderStyle_LightQuadrupleDash: 'q',
+		BorderStyle_HeavyQuadrupleDash: 'Q',
+		BorderStyle_ASCII:              'a',
+	}
+
+	// Directions of the arms in tile patterns, in order. The diagonal arms
+	// can be left out if there are none.
+	patternDirs = []Direction{
+		DirLeft, DirUp, DirRight, DirDown,
+		DirUpLeft, DirUpRight, DirDownRight, DirDownLeft,
 	}
 )
 
-func borderStyleFromChar(c byte) BorderStyle {
-	switch c {
-	case ' ':
-		return BorderStyle_None
-	case 'r':
-		return BorderStyle_Rounded
-	case 'l':
-		return BorderStyle_Light
-	case 'h':
-		return BorderStyle_Heavy
-	case 'd':
-		return BorderStyle_Double
-	default:
-		panic(fmt.Sprintf("Invalid border style character %c", c))
+func borderStyleFromChar(c byte) (BorderStyle, bool) {
+	for bs, ch := range borderStyleChars {
+		if ch == c {
+			return bs, true
+		}
+	}
+	return BorderStyle_None, false
+}
+
+// parseTile parses a tile pattern like " l l", which lists the border
+// styles of the left, up, right and down arms, optionally followed by the
+// up-left, up-right, down-right and down-left arms.
+func parseTile(s string) (Tile, error) {
+	if len(s) != 4 && len(s) != len(patternDirs) {
+		return 0, fmt.Errorf("invalid tile pattern %q", s)
+	}
+	var t Tile
+	for i, d := range patternDirs[:len(s)] {
+		bs, ok := borderStyleFromChar(s[i])
+		if !ok {
+			return 0, fmt.Errorf("invalid border style character %q in tile pattern %q", s[i], s)
+		}
+		t = t.WithDir(d, bs)
 	}
+	return t, nil
 }
 
 func newTile(s string) Tile {
-	return Tile(uint32(borderStyleFromChar(s[0]))<<24 | uint32(borderStyleFromChar(s[1]))<<16 | uint32(borderStyleFromChar(s[2]))<<8 | uint32(borderStyleFromChar(s[3])))
+	t, err := parseTile(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// pattern returns t in the notation understood by parseTile. The diagonal
+// arms are left out if there are none.
+func (t Tile) pattern() string {
+	dirs := patternDirs
+	if t.diagonal() == 0 {
+		dirs = dirs[:4]
+	}
+	p := make([]byte, len(dirs))
+	for i, d := range dirs {
+		p[i] = borderStyleChars[t.Dir(d)]
+	}
+	return string(p)
 }
 
 func tileExchange(t Tile, shift int, border BorderStyle) Tile {
-	mask := ^(uint32(0xff) << shift)
-	return Tile((uint32(t) & mask) | uint32(border) << shift)
+	mask := ^(uint64(0xff) << shift)
+	return Tile((uint64(t) & mask) | uint64(border)<<shift)
 }
 
+// TileFromRune returns the canonical tile of r, or 0 if r is not a border
+// glyph. Rounded arms are only used for arc corners: '│' is " l l", never
+// " r l".
 func TileFromRune(r rune) Tile {
-	for key, val := range tileToRune {
-		if val == r {
-			return key
-		}
+	return runeToTile[r]
+}
+
+func dirShift(dir Direction) int {
+	switch dir {
+	case DirDown:
+		return 0
+	case DirRight:
+		return 8
+	case DirUp:
+		return 16
+	case DirLeft:
+		return 24
+	case DirUpLeft:
+		return 32
+	case DirUpRight:
+		return 40
+	case DirDownRight:
+		return 48
+	case DirDownLeft:
+		return 56
 	}
-	return 0
+	panic("Bad Direction!")
 }
 
 func (t Tile) WithDir(dir Direction, border BorderStyle) Tile {
-	shift := 0
-	switch dir {
-	case DirDown: shift = 0
-	case DirRight: shift = 8
-	case DirUp: shift = 16
-	case DirLeft: shift = 24
+	return tileExchange(t, dirShift(dir), border)
+}
+
+// Dir returns the border style of the arm pointing in direction dir.
+func (t Tile) Dir(dir Direction) BorderStyle {
+	return BorderStyle(uint64(t) >> dirShift(dir) & 0xff)
+}
+
+// Merge returns t with all arms that are set in o replaced by o's arms.
+func (t Tile) Merge(o Tile) Tile {
+	for _, d := range allDirections {
+		if bs := o.Dir(d); bs != BorderStyle_None {
+			t = t.WithDir(d, bs)
+		}
 	}
-	return tileExchange(t, shift, border)
+	return t
 }
 
+// Rune returns the glyph of t, or ' ' if there is none. Dashed arms are
+// shown solid if there is no glyph with them. Unicode has no glyphs where
+// heavy and double lines meet: heavy, then double arms, then both are shown
+// light instead. There are only light diagonal glyphs, and none that
+// combine them with orthogonal arms: those win if there are any. Tiles
+// with ASCII arms are shown in ASCII.
 func (t Tile) Rune() rune {
+	if t.hasStyle(BorderStyle_ASCII) {
+		return t.asciiRune()
+	}
 	if r, ok := tileToRune[t]; ok {
 		return r
 	}
+	s := t.solid()
+	for _, f := range []Tile{
+		s,
+		s.lighten(BorderStyle_Heavy),
+		s.lighten(BorderStyle_Double),
+		s.lighten(BorderStyle_Heavy).lighten(BorderStyle_Double),
+	} {
+		if r, ok := tileToRune[f]; ok {
+			return r
+		}
+	}
+	if t.diagonal() == 0 {
+		return ' '
+	}
+	if o := t.orthogonal(); o != 0 {
+		return o.Rune()
+	}
+	return t.diagonalRune()
+}
+
+// solid returns t with all dashed arms replaced by solid ones.
+func (t Tile) solid() Tile {
+	for _, d := range allDirections {
+		t = t.WithDir(d, t.Dir(d).Solid())
+	}
+	return t
+}
+
+// lighten returns t with all arms in style bs replaced by light ones.
+func (t Tile) lighten(bs BorderStyle) Tile {
+	for _, d := range allDirections {
+		if t.Dir(d) == bs {
+			t = t.WithDir(d, BorderStyle_Light)
+		}
+	}
+	return t
+}
+
+// hasStyle tells whether any arm of t has style bs.
+func (t Tile) hasStyle(bs BorderStyle) bool {
+	for _, d := range allDirections {
+		if t.Dir(d) == bs {
+			return true
+		}
+	}
+	return false
+}
+
+// orthogonal returns t without its diagonal arms.
+func (t Tile) orthogonal() Tile {
+	return t & 0xffffffff
+}
+
+// diagonal returns t without its orthogonal arms.
+func (t Tile) diagonal() Tile {
+	return t &^ 0xffffffff
+}
+
+// diagonalRune returns the glyph of the diagonal arms of t. A single arm is
+// shown as the complete diagonal.
+func (t Tile) diagonalRune() rune {
+	slash := t.Dir(DirUpRight) != BorderStyle_None || t.Dir(DirDownLeft) != BorderStyle_None
+	backslash := t.Dir(DirUpLeft) != BorderStyle_None || t.Dir(DirDownRight) != BorderStyle_None
+	switch {
+	case slash && backslash:
+		return '╳'
+	case slash:
+		return '╱'
+	case backslash:
+		return '╲'
+	}
 	return ' '
 }
 
+// asciiRune returns the 7-bit glyph of t: '-' and '|' for horizontal and
+// vertical lines, '+' for everything else, and '/', '\' and 'X' for
+// diagonals.
+func (t Tile) asciiRune() rune {
+	if o := t.orthogonal(); o != 0 {
+		h := o.Dir(DirLeft) != BorderStyle_None || o.Dir(DirRight) != BorderStyle_None
+		v := o.Dir(DirUp) != BorderStyle_None || o.Dir(DirDown) != BorderStyle_None
+		switch {
+		case h && v:
+			return '+'
+		case h:
+			return '-'
+		default:
+			return '|'
+		}
+	}
+	switch t.diagonalRune() {
+	case '╱':
+		return '/'
+	case '╲':
+		return '\\'
+	case '╳':
+		return 'X'
+	}
+	return ' '
+}
@@ -0,0 +1,488 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Code generated by gentiles; DO NOT EDIT.
+
+package termdraw
+
+// tileToRune maps tiles to their glyphs.
+var tileToRune = map[Tile]rune{
+	newTile("     l l"): '╱',
+	newTile("    l l "): '╲',
+	newTile("    llll"): '╳',
+	newTile("   h"):     '╻',
+	newTile("   l"):     '╷',
+	newTile("   r"):     '╷',
+	newTile("  dd"):     '╔',
+	newTile("  dl"):     '╒',
+	newTile("  dr"):     '╒',
+	newTile("  h "):     '╺',
+	newTile("  hh"):     '┏',
+	newTile("  hl"):     '┍',
+	newTile("  hr"):     '┍',
+	newTile("  l "):     '╶',
+	newTile("  ld"):     '╓',
+	newTile("  lh"):     '┎',
+	newTile("  ll"):     '┌',
+	newTile("  lr"):     '┌',
+	newTile("  r "):     '╶',
+	newTile("  rd"):     '╓',
+	newTile("  rh"):     '┎',
+	newTile("  rl"):     '┌',
+	newTile("  rr"):     '╭',
+	newTile(" B B"):     '╏',
+	newTile(" Q Q"):     '┋',
+	newTile(" T T"):     '┇',
+	newTile(" b b"):     '╎',
+	newTile(" d d"):     '║',
+	newTile(" dd "):     '╚',
+	newTile(" ddd"):     '╠',
+	newTile(" dl "):     '╙',
+	newTile(" dld"):     '╟',
+	newTile(" dr "):     '╙',
+	newTile(" drd"):     '╟',
+	newTile(" h  "):     '╹',
+	newTile(" h h"):     '┃',
+	newTile(" h l"):     '╿',
+	newTile(" h r"):     '╿',
+	newTile(" hh "):     '┗',
+	newTile(" hhh"):     '┣',
+	newTile(" hhl"):     '┡',
+	newTile(" hhr"):     '┡',
+	newTile(" hl "):     '┖',
+	newTile(" hlh"):     '┠',
+	newTile(" hll"):     '┞',
+	newTile(" hlr"):     '┞',
+	newTile(" hr "):     '┖',
+	newTile(" hrh"):     '┠',
+	newTile(" hrl"):     '┞',
+	newTile(" hrr"):     '┞',
+	newTile(" l  "):     '╵',
+	newTile(" l h"):     '╽',
+	newTile(" l l"):     '│',
+	newTile(" l r"):     '│',
+	newTile(" ld "):     '╘',
+	newTile(" ldl"):     '╞',
+	newTile(" ldr"):     '╞',
+	newTile(" lh "):     '┕',
+	newTile(" lhh"):     '┢',
+	newTile(" lhl"):     '┝',
+	newTile(" lhr"):     '┝',
+	newTile(" ll "):     '└',
+	newTile(" llh"):     '┟',
+	newTile(" lll"):     '├',
+	newTile(" llr"):     '├',
+	newTile(" lr "):     '└',
+	newTile(" lrh"):     '┟',
+	newTile(" lrl"):     '├',
+	newTile(" lrr"):     '├',
+	newTile(" q q"):     '┊',
+	newTile(" r  "):     '╵',
+	newTile(" r h"):     '╽',
+	newTile(" r l"):     '│',
+	newTile(" r r"):     '│',
+	newTile(" rd "):     '╘',
+	newTile(" rdl"):     '╞',
+	newTile(" rdr"):     '╞',
+	newTile(" rh "):     '┕',
+	newTile(" rhh"):     '┢',
+	newTile(" rhl"):     '┝',
+	newTile(" rhr"):     '┝',
+	newTile(" rl "):     '└',
+	newTile(" rlh"):     '┟',
+	newTile(" rll"):     '├',
+	newTile(" rlr"):     '├',
+	newTile(" rr "):     '╰',
+	newTile(" rrh"):     '┟',
+	newTile(" rrl"):     '├',
+	newTile(" rrr"):     '├',
+	newTile(" t t"):     '┆',
+	newTile("B B "):     '╍',
+	newTile("Q Q "):     '┉',
+	newTile("T T "):     '┅',
+	newTile("b b "):     '╌',
+	newTile("d  d"):     '╗',
+	newTile("d  l"):     '╕',
+	newTile("d  r"):     '╕',
+	newTile("d d "):     '═',
+	newTile("d dd"):     '╦',
+	newTile("d dl"):     '╤',
+	newTile("d dr"):     '╤',
+	newTile("dd  "):     '╝',
+	newTile("dd d"):     '╣',
+	newTile("ddd "):     '╩',
+	newTile("dddd"):     '╬',
+	newTile("dl  "):     '╛',
+	newTile("dl l"):     '╡',
+	newTile("dl r"):     '╡',
+	newTile("dld "):     '╧',
+	newTile("dldl"):     '╪',
+	newTile("dldr"):     '╪',
+	newTile("dr  "):     '╛',
+	newTile("dr l"):     '╡',
+	newTile("dr r"):     '╡',
+	newTile("drd "):     '╧',
+	newTile("drdl"):     '╪',
+	newTile("drdr"):     '╪',
+	newTile("h   "):     '╸',
+	newTile("h  h"):     '┓',
+	newTile("h  l"):     '┑',
+	newTile("h  r"):     '┑',
+	newTile("h h "):     '━',
+	newTile("h hh"):     '┳',
+	newTile("h hl"):     '┯',
+	newTile("h hr"):     '┯',
+	newTile("h l "):     '╾',
+	newTile("h lh"):     '┱',
+	newTile("h ll"):     '┭',
+	newTile("h lr"):     '┭',
+	newTile("h r "):     '╾',
+	newTile("h rh"):     '┱',
+	newTile("h rl"):     '┭',
+	newTile("h rr"):     '┭',
+	newTile("hh  "):     '┛',
+	newTile("hh h"):     '┫',
+	newTile("hh l"):     '┩',
+	newTile("hh r"):     '┩',
+	newTile("hhh "):     '┻',
+	newTile("hhhh"):     '╋',
+	newTile("hhhl"):     '╇',
+	newTile("hhhr"):     '╇',
+	newTile("hhl "):     '┹',
+	newTile("hhlh"):     '╉',
+	newTile("hhll"):     '╃',
+	newTile("hhlr"):     '╃',
+	newTile("hhr "):     '┹',
+	newTile("hhrh"):     '╉',
+	newTile("hhrl"):     '╃',
+	newTile("hhrr"):     '╃',
+	newTile("hl  "):     '┙',
+	newTile("hl h"):     '┪',
+	newTile("hl l"):     '┥',
+	newTile("hl r"):     '┥',
+	newTile("hlh "):     '┷',
+	newTile("hlhh"):     '╈',
+	newTile("hlhl"):     '┿',
+	newTile("hlhr"):     '┿',
+	newTile("hll "):     '┵',
+	newTile("hllh"):     '╅',
+	newTile("hlll"):     '┽',
+	newTile("hllr"):     '┽',
+	newTile("hlr "):     '┵',
+	newTile("hlrh"):     '╅',
+	newTile("hlrl"):     '┽',
+	newTile("hlrr"):     '┽',
+	newTile("hr  "):     '┙',
+	newTile("hr h"):     '┪',
+	newTile("hr l"):     '┥',
+	newTile("hr r"):     '┥',
+	newTile("hrh "):     '┷',
+	newTile("hrhh"):     '╈',
+	newTile("hrhl"):     '┿',
+	newTile("hrhr"):     '┿',
+	newTile("hrl "):     '┵',
+	newTile("hrlh"):     '╅',
+	newTile("hrll"):     '┽',
+	newTile("hrlr"):     '┽',
+	newTile("hrr "):     '┵',
+	newTile("hrrh"):     '╅',
+	newTile("hrrl"):     '┽',
+	newTile("hrrr"):     '┽',
+	newTile("l   "):     '╴',
+	newTile("l  d"):     '╖',
+	newTile("l  h"):     '┒',
+	newTile("l  l"):     '┐',
+	newTile("l  r"):     '┐',
+	newTile("l h "):     '╼',
+	newTile("l hh"):     '┲',
+	newTile("l hl"):     '┮',
+	newTile("l hr"):     '┮',
+	newTile("l l "):     '─',
+	newTile("l ld"):     '╥',
+	newTile("l lh"):     '┰',
+	newTile("l ll"):     '┬',
+	newTile("l lr"):     '┬',
+	newTile("l r "):     '─',
+	newTile("l rd"):     '╥',
+	newTile("l rh"):     '┰',
+	newTile("l rl"):     '┬',
+	newTile("l rr"):     '┬',
+	newTile("ld  "):     '╜',
+	newTile("ld d"):     '╢',
+	newTile("ldl "):     '╨',
+	newTile("ldld"):     '╫',
+	newTile("ldr "):     '╨',
+	newTile("ldrd"):     '╫',
+	newTile("lh  "):     '┚',
+	newTile("lh h"):     '┨',
+	newTile("lh l"):     '┦',
+	newTile("lh r"):     '┦',
+	newTile("lhh "):     '┺',
+	newTile("lhhh"):     '╊',
+	newTile("lhhl"):     '╄',
+	newTile("lhhr"):     '╄',
+	newTile("lhl "):     '┸',
+	newTile("lhlh"):     '╂',
+	newTile("lhll"):     '╀',
+	newTile("lhlr"):     '╀',
+	newTile("lhr "):     '┸',
+	newTile("lhrh"):     '╂',
+	newTile("lhrl"):     '╀',
+	newTile("lhrr"):     '╀',
+	newTile("ll  "):     '┘',
+	newTile("ll h"):     '┧',
+	newTile("ll l"):     '┤',
+	newTile("ll r"):     '┤',
+	newTile("llh "):     '┶',
+	newTile("llhh"):     '╆',
+	newTile("llhl"):     '┾',
+	newTile("llhr"):     '┾',
+	newTile("lll "):     '┴',
+	newTile("lllh"):     '╁',
+	newTile("llll"):     '┼',
+	newTile("lllr"):     '┼',
+	newTile("llr "):     '┴',
+	newTile("llrh"):     '╁',
+	newTile("llrl"):     '┼',
+	newTile("llrr"):     '┼',
+	newTile("lr  "):     '┘',
+	newTile("lr h"):     '┧',
+	newTile("lr l"):     '┤',
+	newTile("lr r"):     '┤',
+	newTile("lrh "):     '┶',
+	newTile("lrhh"):     '╆',
+	newTile("lrhl"):     '┾',
+	newTile("lrhr"):     '┾',
+	newTile("lrl "):     '┴',
+	newTile("lrlh"):     '╁',
+	newTile("lrll"):     '┼',
+	newTile("lrlr"):     '┼',
+	newTile("lrr "):     '┴',
+	newTile("lrrh"):     '╁',
+	newTile("lrrl"):     '┼',
+	newTile("lrrr"):     '┼',
+	newTile("q q "):     '┈',
+	newTile("r   "):     '╴',
+	newTile("r  d"):     '╖',
+	newTile("r  h"):     '┒',
+	newTile("r  l"):     '┐',
+	newTile("r  r"):     '╮',
+	newTile("r h "):     '╼',
+	newTile("r hh"):     '┲',
+	newTile("r hl"):     '┮',
+	newTile("r hr"):     '┮',
+	newTile("r l "):     '─',
+	newTile("r ld"):     '╥',
+	newTile("r lh"):     '┰',
+	newTile("r ll"):     '┬',
+	newTile("r lr"):     '┬',
+	newTile("r r "):     '─',
+	newTile("r rd"):     '╥',
+	newTile("r rh"):     '┰',
+	newTile("r rl"):     '┬',
+	newTile("r rr"):     '┬',
+	newTile("rd  "):     '╜',
+	newTile("rd d"):     '╢',
+	newTile("rdl "):     '╨',
+	newTile("rdld"):     '╫',
+	newTile("rdr "):     '╨',
+	newTile("rdrd"):     '╫',
+	newTile("rh  "):     '┚',
+	newTile("rh h"):     '┨',
+	newTile("rh l"):     '┦',
+	newTile("rh r"):     '┦',
+	newTile("rhh "):     '┺',
+	newTile("rhhh"):     '╊',
+	newTile("rhhl"):     '╄',
+	newTile("rhhr"):     '╄',
+	newTile("rhl "):     '┸',
+	newTile("rhlh"):     '╂',
+	newTile("rhll"):     '╀',
+	newTile("rhlr"):     '╀',
+	newTile("rhr "):     '┸',
+	newTile("rhrh"):     '╂',
+	newTile("rhrl"):     '╀',
+	newTile("rhrr"):     '╀',
+	newTile("rl  "):     '┘',
+	newTile("rl h"):     '┧',
+	newTile("rl l"):     '┤',
+	newTile("rl r"):     '┤',
+	newTile("rlh "):     '┶',
+	newTile("rlhh"):     '╆',
+	newTile("rlhl"):     '┾',
+	newTile("rlhr"):     '┾',
+	newTile("rll "):     '┴',
+	newTile("rllh"):     '╁',
+	newTile("rlll"):     '┼',
+	newTile("rllr"):     '┼',
+	newTile("rlr "):     '┴',
+	newTile("rlrh"):     '╁',
+	newTile("rlrl"):     '┼',
+	newTile("rlrr"):     '┼',
+	newTile("rr  "):     '╯',
+	newTile("rr h"):     '┧',
+	newTile("rr l"):     '┤',
+	newTile("rr r"):     '┤',
+	newTile("rrh "):     '┶',
+	newTile("rrhh"):     '╆',
+	newTile("rrhl"):     '┾',
+	newTile("rrhr"):     '┾',
+	newTile("rrl "):     '┴',
+	newTile("rrlh"):     '╁',
+	newTile("rrll"):     '┼',
+	newTile("rrlr"):     '┼',
+	newTile("rrr "):     '┴',
+	newTile("rrrh"):     '╁',
+	newTile("rrrl"):     '┼',
+	newTile("rrrr"):     '┼',
+	newTile("t t "):     '┄',
+}
+
+// runeToTile maps glyphs to their canonical tiles: where several tiles share
+// a glyph, light arms are preferred over rounded ones.
+var runeToTile = map[rune]Tile{
+	'─': newTile("l l "),
+	'━': newTile("h h "),
+	'│': newTile(" l l"),
+	'┃': newTile(" h h"),
+	'┄': newTile("t t "),
+	'┅': newTile("T T "),
+	'┆': newTile(" t t"),
+	'┇': newTile(" T T"),
+	'┈': newTile("q q "),
+	'┉': newTile("Q Q "),
+	'┊': newTile(" q q"),
+	'┋': newTile(" Q Q"),
+	'┌': newTile("  ll"),
+	'┍': newTile("  hl"),
+	'┎': newTile("  lh"),
+	'┏': newTile("  hh"),
+	'┐': newTile("l  l"),
+	'┑': newTile("h  l"),
+	'┒': newTile("l  h"),
+	'┓': newTile("h  h"),
+	'└': newTile(" ll "),
+	'┕': newTile(" lh "),
+	'┖': newTile(" hl "),
+	'┗': newTile(" hh "),
+	'┘': newTile("ll  "),
+	'┙': newTile("hl  "),
+	'┚': newTile("lh  "),
+	'┛': newTile("hh  "),
+	'├': newTile(" lll"),
+	'┝': newTile(" lhl"),
+	'┞': newTile(" hll"),
+	'┟': newTile(" llh"),
+	'┠': newTile(" hlh"),
+	'┡': newTile(" hhl"),
+	'┢': newTile(" lhh"),
+	'┣': newTile(" hhh"),
+	'┤': newTile("ll l"),
+	'┥': newTile("hl l"),
+	'┦': newTile("lh l"),
+	'┧': newTile("ll h"),
+	'┨': newTile("lh h"),
+	'┩': newTile("hh l"),
+	'┪': newTile("hl h"),
+	'┫': newTile("hh h"),
+	'┬': newTile("l ll"),
+	'┭': newTile("h ll"),
+	'┮': newTile("l hl"),
+	'┯': newTile("h hl"),
+	'┰': newTile("l lh"),
+	'┱': newTile("h lh"),
+	'┲': newTile("l hh"),
+	'┳': newTile("h hh"),
+	'┴': newTile("lll "),
+	'┵': newTile("hll "),
+	'┶': newTile("llh "),
+	'┷': newTile("hlh "),
+	'┸': newTile("lhl "),
+	'┹': newTile("hhl "),
+	'┺': newTile("lhh "),
+	'┻': newTile("hhh "),
+	'┼': newTile("llll"),
+	'┽': newTile("hlll"),
+	'┾': newTile("llhl"),
+	'┿': newTile("hlhl"),
+	'╀': newTile("lhll"),
+	'╁': newTile("lllh"),
+	'╂': newTile("lhlh"),
+	'╃': newTile("hhll"),
+	'╄': newTile("lhhl"),
+	'╅': newTile("hllh"),
+	'╆': newTile("llhh"),
+	'╇': newTile("hhhl"),
+	'╈': newTile("hlhh"),
+	'╉': newTile("hhlh"),
+	'╊': newTile("lhhh"),
+	'╋': newTile("hhhh"),
+	'╌': newTile("b b "),
+	'╍': newTile("B B "),
+	'╎': newTile(" b b"),
+	'╏': newTile(" B B"),
+	'═': newTile("d d "),
+	'║': newTile(" d d"),
+	'╒': newTile("  dl"),
+	'╓': newTile("  ld"),
+	'╔': newTile("  dd"),
+	'╕': newTile("d  l"),
+	'╖': newTile("l  d"),
+	'╗': newTile("d  d"),
+	'╘': newTile(" ld "),
+	'╙': newTile(" dl "),
+	'╚': newTile(" dd "),
+	'╛': newTile("dl  "),
+	'╜': newTile("ld  "),
+	'╝': newTile("dd  "),
+	'╞': newTile(" ldl"),
+	'╟': newTile(" dld"),
+	'╠': newTile(" ddd"),
+	'╡': newTile("dl l"),
+	'╢': newTile("ld d"),
+	'╣': newTile("dd d"),
+	'╤': newTile("d dl"),
+	'╥': newTile("l ld"),
+	'╦': newTile("d dd"),
+	'╧': newTile("dld "),
+	'╨': newTile("ldl "),
+	'╩': newTile("ddd "),
+	'╪': newTile("dldl"),
+	'╫': newTile("ldld"),
+	'╬': newTile("dddd"),
+	'╭': newTile("  rr"),
+	'╮': newTile("r  r"),
+	'╯': newTile("rr  "),
+	'╰': newTile(" rr "),
+	'╱': newTile("     l l"),
+	'╲': newTile("    l l "),
+	'╳': newTile("    llll"),
+	'╴': newTile("l   "),
+	'╵': newTile(" l  "),
+	'╶': newTile("  l "),
+	'╷': newTile("   l"),
+	'╸': newTile("h   "),
+	'╹': newTile(" h  "),
+	'╺': newTile("  h "),
+	'╻': newTile("   h"),
+	'╼': newTile("l h "),
+	'╽': newTile(" l h"),
+	'╾': newTile("h l "),
+	'╿': newTile(" h l"),
+}
@@ -0,0 +1,443 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package termdraw
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+func TestTileRoundTrip(t *testing.T) {
+	for tile, r := range tileToRune {
+		if got := tile.Rune(); got != r {
+			t.Errorf("%q.Rune() = %c, want %c", tile.pattern(), got, r)
+		}
+		if got := TileFromRune(r).Rune(); got != r {
+			t.Errorf("TileFromRune(%c).Rune() = %c", r, got)
+		}
+		if got, err := parseTile(tile.pattern()); err != nil || got != tile {
+			t.Errorf("parseTile(%q) = %v, %v", tile.pattern(), got, err)
+		}
+	}
+}
+
+// Rounded arms look like light ones, except in arc corners.
+func TestRoundedTilesLookLight(t *testing.T) {
+	for tile, r := range tileToRune {
+		if _, _, ok := tile.arcCorner(); ok {
+			continue
+		}
+		p := tile.pattern()
+		if !strings.Contains(p, "r") {
+			continue
+		}
+		light := newTile(strings.ReplaceAll(p, "r", "l"))
+		if want := light.Rune(); r != want {
+			t.Errorf("%q is %c, but %q is %c", p, r, light.pattern(), want)
+		}
+	}
+}
+
+func TestTileFromRuneUnknown(t *testing.T) {
+	for _, r := range "a +-|" {
+		if tile := TileFromRune(r); tile != 0 {
+			t.Errorf("TileFromRune(%q) = %q, want 0", r, tile.pattern())
+		}
+	}
+}
+
+// cross draws a horizontal line in style h and then a vertical one in style
+// v, crossing at 1,1.
+func cross(h, v BorderStyle) *Canvas {
+	c := NewCanvas(0, 0, 3, 3)
+	c.strokeN(Pos{0, 1}, DirRight, 2, h)
+	c.strokeN(Pos{1, 0}, DirDown, 2, v)
+	return c
+}
+
+func TestCrossings(t *testing.T) {
+	tests := []struct {
+		h, v BorderStyle
+		want rune
+	}{
+		{BorderStyle_Light, BorderStyle_Light, '┼'},
+		{BorderStyle_Light, BorderStyle_Rounded, '┼'},
+		{BorderStyle_Rounded, BorderStyle_Rounded, '┼'},
+		{BorderStyle_Light, BorderStyle_Heavy, '╂'},
+		{BorderStyle_Heavy, BorderStyle_Light, '┿'},
+		{BorderStyle_Rounded, BorderStyle_Heavy, '╂'},
+		{BorderStyle_Heavy, BorderStyle_Heavy, '╋'},
+		{BorderStyle_Light, BorderStyle_Double, '╫'},
+		{BorderStyle_Double, BorderStyle_Light, '╪'},
+		{BorderStyle_Double, BorderStyle_Rounded, '╪'},
+		{BorderStyle_Double, BorderStyle_Double, '╬'},
+	}
+	for _, tc := range tests {
+		c := cross(tc.h, tc.v)
+		if got := c.cellAt(Pos{1, 1}).ch; got != tc.want {
+			t.Errorf("%s horizontal crossed by %s vertical: got %c, want %c", tc.h, tc.v, got, tc.want)
+		}
+	}
+}
+
+// Unicode has no glyphs for heavy lines meeting double ones, they are shown
+// light instead.
+func TestCrossingsWithoutGlyph(t *testing.T) {
+	tests := []struct {
+		h, v BorderStyle
+		want rune
+	}{
+		{BorderStyle_Heavy, BorderStyle_Double, '╫'},
+		{BorderStyle_Double, BorderStyle_Heavy, '╪'},
+		{BorderStyle_HeavyTripleDash, BorderStyle_Double, '╫'},
+	}
+	for _, tc := range tests {
+		c := cross(tc.h, tc.v)
+		if got := c.cellAt(Pos{1, 1}).ch; got != tc.want {
+			t.Errorf("%s horizontal crossed by %s vertical: got %c, want %c", tc.h, tc.v, got, tc.want)
+		}
+		// The tile itself is unchanged
+		if got := c.Tile(Pos{1, 1}).Dir(DirLeft); got != tc.h {
+			t.Errorf("%s horizontal crossed by %s vertical: left arm is %s", tc.h, tc.v, got)
+		}
+	}
+
+	// Every combination of styles has a glyph
+	styles := []BorderStyle{BorderStyle_None, BorderStyle_Light, BorderStyle_Rounded, BorderStyle_Heavy, BorderStyle_Double}
+	for _, l := range styles {
+		for _, u := range styles {
+			for _, r := range styles {
+				for _, d := range styles {
+					tile := Tile(0).WithDir(DirLeft, l).WithDir(DirUp, u).WithDir(DirRight, r).WithDir(DirDown, d)
+					if tile != 0 && tile.Rune() == ' ' {
+						t.Errorf("%q has no glyph", tile.pattern())
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestJunctions(t *testing.T) {
+	tests := []struct {
+		line, branch BorderStyle
+		want         rune
+	}{
+		{BorderStyle_Light, BorderStyle_Light, '┬'},
+		{BorderStyle_Light, BorderStyle_Heavy, '┰'},
+		{BorderStyle_Heavy, BorderStyle_Light, '┯'},
+		{BorderStyle_Heavy, BorderStyle_Heavy, '┳'},
+		{BorderStyle_Light, BorderStyle_Double, '╥'},
+		{BorderStyle_Double, BorderStyle_Light, '╤'},
+		{BorderStyle_Double, BorderStyle_Double, '╦'},
+	}
+	for _, tc := range tests {
+		c := NewCanvas(0, 0, 3, 3)
+		c.strokeN(Pos{0, 0}, DirRight, 2, tc.line)
+		c.Stroke(Pos{1, 0}, DirDown, tc.branch)
+		if got := c.cellAt(Pos{1, 0}).ch; got != tc.want {
+			t.Errorf("%s line with %s branch: got %c, want %c", tc.line, tc.branch, got, tc.want)
+		}
+	}
+}
+
+type testStroke struct {
+	p  Pos
+	d  Direction
+	bs BorderStyle
+}
+
+// decodeStrokes turns random numbers into strokes on a 4x4 grid, drawing
+// every segment at most once.
+func decodeStrokes(vals []uint16) []testStroke {
+	styles := []BorderStyle{BorderStyle_Light, BorderStyle_Rounded, BorderStyle_Heavy, BorderStyle_Double}
+	seen := make(map[[2]Pos]bool)
+	var res []testStroke
+	for _, v := range vals {
+		s := testStroke{
+			p:  Pos{int(v & 3), int(v >> 2 & 3)},
+			d:  directions[v>>4&3],
+			bs: styles[v>>6&3],
+		}
+		n := s.p.Step(s.d)
+		if n.X < 0 || n.Y < 0 {
+			continue
+		}
+		seg := [2]Pos{s.p, n}
+		if n.X < s.p.X || n.Y < s.p.Y {
+			seg = [2]Pos{n, s.p}
+		}
+		if seen[seg] {
+			continue
+		}
+		seen[seg] = true
+		res = append(res, s)
+	}
+	return res
+}
+
+func drawStrokes(strokes []testStroke) *Canvas {
+	c := NewCanvas(0, 0, 5, 5)
+	for _, s := range strokes {
+		c.Stroke(s.p, s.d, s.bs)
+	}
+	return c
+}
+
+// The order in which segments are drawn doesn't matter.
+func TestStrokeCommutativity(t *testing.T) {
+	f := func(vals []uint16) bool {
+		strokes := decodeStrokes(vals)
+		reversed := make([]testStroke, len(strokes))
+		for i, s := range strokes {
+			reversed[len(strokes)-1-i] = s
+		}
+		a, b := drawStrokes(strokes), drawStrokes(reversed)
+		for y := 0; y < 5; y++ {
+			for x := 0; x < 5; x++ {
+				if a.Tile(Pos{x, y}) != b.Tile(Pos{x, y}) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// Drawing a segment backwards is the same as drawing it forwards.
+func TestStrokeDirection(t *testing.T) {
+	f := func(vals []uint16) bool {
+		strokes := decodeStrokes(vals)
+		flipped := make([]testStroke, len(strokes))
+		for i, s := range strokes {
+			flipped[i] = testStroke{s.p.Step(s.d), s.d.Inverse(), s.bs}
+		}
+		a, b := drawStrokes(strokes), drawStrokes(flipped)
+		for y := 0; y < 5; y++ {
+			for x := 0; x < 5; x++ {
+				if a.Tile(Pos{x, y}) != b.Tile(Pos{x, y}) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCanonicalTiles(t *testing.T) {
+	for r, tile := range runeToTile {
+		if tile.Rune() != r {
+			t.Errorf("canonical tile %q of %c is %c", tile.pattern(), r, tile.Rune())
+		}
+		if _, _, ok := tile.arcCorner(); ok {
+			continue
+		}
+		if strings.Contains(tile.pattern(), "r") {
+			t.Errorf("canonical tile of %c is %q, which has rounded arms", r, tile.pattern())
+		}
+	}
+	for r, want := range map[rune]string{'│': " l l", '┼': "llll", '╰': " rr "} {
+		if got := TileFromRune(r).pattern(); got != want {
+			t.Errorf("TileFromRune(%c) = %q, want %q", r, got, want)
+		}
+	}
+}
+
+// Loading a drawing and continuing it gives the same result every time.
+func TestReloadAndRedraw(t *testing.T) {
+	text := []string{
+		"╭──┬──╮",
+		"│  │  │",
+		"╰──┴──╯",
+	}
+	want := []string{
+		"╭──┬──╮",
+		"├──┼──┤",
+		"╰──┴──╯",
+	}
+	for i := 0; i < 20; i++ {
+		c := NewCanvas(0, 0, 10, 5)
+		c.SetText(text)
+		c.strokeN(Pos{0, 1}, DirRight, 6, BorderStyle_Light)
+		if got := c.AsText(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("run %d: got %q, want %q", i, got, want)
+		}
+		if got := c.Tile(Pos{0, 1}); got != newTile(" lll") {
+			t.Fatalf("run %d: tile at 0,1 is %q, want \" lll\"", i, got.pattern())
+		}
+	}
+}
+
+func TestDashedLines(t *testing.T) {
+	tests := []struct {
+		bs                        BorderStyle
+		horizontal, vertical, box rune
+	}{
+		{BorderStyle_LightDoubleDash, '╌', '╎', '┌'},
+		{BorderStyle_HeavyDoubleDash, '╍', '╏', '┏'},
+		{BorderStyle_LightTripleDash, '┄', '┆', '┌'},
+		{BorderStyle_HeavyTripleDash, '┅', '┇', '┏'},
+		{BorderStyle_LightQuadrupleDash, '┈', '┊', '┌'},
+		{BorderStyle_HeavyQuadrupleDash, '┉', '┋', '┏'},
+	}
+	for _, tc := range tests {
+		c := NewCanvas(0, 0, 5, 5)
+		c.DrawBox(Rect{0, 0, 4, 3}, tc.bs)
+		if got := c.cellAt(Pos{1, 0}).ch; got != tc.horizontal {
+			t.Errorf("%s: horizontal line is %c, want %c", tc.bs, got, tc.horizontal)
+		}
+		if got := c.cellAt(Pos{0, 1}).ch; got != tc.vertical {
+			t.Errorf("%s: vertical line is %c, want %c", tc.bs, got, tc.vertical)
+		}
+		// No dashed corners, they degrade to solid ones
+		if got := c.cellAt(Pos{0, 0}).ch; got != tc.box {
+			t.Errorf("%s: corner is %c, want %c", tc.bs, got, tc.box)
+		}
+		if got := c.Tile(Pos{0, 0}).Dir(DirRight); got != tc.bs {
+			t.Errorf("%s: corner tile has %s arm", tc.bs, got)
+		}
+		if got := TileFromRune(tc.horizontal).Dir(DirLeft); got != tc.bs {
+			t.Errorf("TileFromRune(%c) has %s arm, want %s", tc.horizontal, got, tc.bs)
+		}
+	}
+}
+
+func TestDashedCrossings(t *testing.T) {
+	tests := []struct {
+		h, v BorderStyle
+		want rune
+	}{
+		{BorderStyle_LightTripleDash, BorderStyle_Light, '┼'},
+		{BorderStyle_HeavyTripleDash, BorderStyle_Light, '┿'},
+		{BorderStyle_LightDoubleDash, BorderStyle_HeavyQuadrupleDash, '╂'},
+		{BorderStyle_LightQuadrupleDash, BorderStyle_Double, '╫'},
+	}
+	for _, tc := range tests {
+		c := cross(tc.h, tc.v)
+		if got := c.cellAt(Pos{1, 1}).ch; got != tc.want {
+			t.Errorf("%s horizontal crossed by %s vertical: got %c, want %c", tc.h, tc.v, got, tc.want)
+		}
+		// The line continues dashed next to the crossing
+		if got := c.Tile(Pos{0, 1}).Dir(DirRight); got != tc.h {
+			t.Errorf("%s horizontal: arm next to crossing is %s", tc.h, got)
+		}
+	}
+}
+
+func TestDiagonals(t *testing.T) {
+	c := NewCanvas(0, 0, 10, 10)
+	c.strokeN(Pos{0, 0}, DirDownRight, 4, BorderStyle_Light)
+	c.strokeN(Pos{4, 0}, DirDownLeft, 4, BorderStyle_Light)
+	want := []string{
+		"╲   ╱",
+		" ╲ ╱",
+		"  ╳",
+		" ╱ ╲",
+		"╱   ╲",
+	}
+	if got := c.AsText(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := c.Tile(Pos{2, 2}); got != newTile("    llll") {
+		t.Errorf("crossing is %q, want \"    llll\"", got.pattern())
+	}
+	if got := TileFromRune('╳'); got != newTile("    llll") {
+		t.Errorf("TileFromRune(╳) = %q", got.pattern())
+	}
+
+	// Ends show the complete diagonal, and orthogonal arms win over
+	// diagonal ones
+	for p, want := range map[string]rune{
+		"     l  ": '╱',
+		"    h   ": '╲',
+		"  l l   ": '╶',
+		"l l l l ": '─',
+	} {
+		if got := newTile(p).Rune(); got != want {
+			t.Errorf("%q.Rune() = %c, want %c", p, got, want)
+		}
+	}
+}
+
+func TestDiagonalsInDocument(t *testing.T) {
+	c := NewCanvas(0, 0, 10, 10)
+	c.strokeN(Pos{0, 0}, DirRight, 3, BorderStyle_Double)
+	c.strokeN(Pos{0, 1}, DirDownRight, 2, BorderStyle_Heavy)
+	var buf bytes.Buffer
+	if err := c.WriteDocument(&buf); err != nil {
+		t.Fatal(err)
+	}
+	c2 := NewCanvas(0, 0, 10, 10)
+	if err := c2.ReadDocument(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			p := Pos{x, y}
+			if got, want := c2.Tile(p), c.Tile(p); got != want {
+				t.Errorf("tile at %v is %q, want %q", p, got.pattern(), want.pattern())
+			}
+		}
+	}
+}
+
+func TestErase(t *testing.T) {
+	c := NewCanvas(0, 0, 10, 10)
+	c.SetText([]string{
+		"┌─┬─┐",
+		"│ │ │",
+		"├─┼─┤  text",
+		"└─┴─┘",
+	})
+	// Retract the middle line from the bottom to the crossing
+	c.Erase(Pos{2, 3}, DirUp)
+	c.Erase(Pos{2, 2}, DirUp)
+	// Remove the right half of the middle row, and the text stays
+	for x := 2; x < 7; x++ {
+		c.Erase(Pos{x, 2}, DirRight)
+	}
+	want := []string{
+		"┌─┬─┐",
+		"│ ╵ │",
+		"├─╴ │  text",
+		"└───┘",
+	}
+	if got := c.AsText(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Fully erased cells become blank
+	c.Erase(Pos{1, 2}, DirRight)
+	c.Erase(Pos{2, 1}, DirUp)
+	if got := c.AsText()[1:3]; !reflect.DeepEqual(got, []string{"│   │", "├╴  │  text"}) {
+		t.Errorf("got %q", got)
+	}
+	if got := c.Tile(Pos{2, 2}); got != 0 {
+		t.Errorf("tile at 2,2 is %q, want none", got.pattern())
+	}
+}
@@ -19,77 +19,77 @@
 package termdraw
 
 import (
+	"strings"
 	"unicode/utf8"
 
 	"github.com/asig/termbox-go"
 )
 
 type block struct {
+	screen     Screen
 	x, y, w, h int
 	data       []termbox.Cell
 }
 
-func saveBlock(x, y, w, h int) block {
+func saveBlock(screen Screen, x, y, w, h int) block {
 	b := block{
-		x:    x,
-		y:    y,
-		w:    w,
-		h:    h,
-		data: make([]termbox.Cell, w*h),
+		screen: screen,
+		x:      x,
+		y:      y,
+		w:      w,
+		h:      h,
+		data:   make([]termbox.Cell, w*h),
 	}
 
-	termW, _ := termbox.Size()
-	cells := termbox.CellBuffer()
 	for i := 0; i < h; i++ {
 		for j := 0; j < w; j++ {
-			b.data[i*w+j] = cells[(y+i)*termW+x+j]
+			b.data[i*w+j] = screen.Cell(x+j, y+i)
 		}
 	}
 	return b
 }
 
 func restoreBlock(b block) {
-	termW, _ := termbox.Size()
-	cells := termbox.CellBuffer()
 	for i := 0; i < b.h; i++ {
 		for j := 0; j < b.w; j++ {
-			cells[(b.y+i)*termW+b.x+j] = b.data[i*b.w+j]
+			cl := b.data[i*b.w+j]
+			b.screen.SetCell(b.x+j, b.y+i, cl.Ch, cl.Fg, cl.Bg)
 		}
 	}
 }
 
-func DrawBox(x, y, w, h int, fg termbox.Attribute, bg termbox.Attribute, bs BorderStyle) {
+func DrawBox(screen Screen, x, y, w, h int, fg termbox.Attribute, bg termbox.Attribute, bs BorderStyle) {
 	border := borderMap[bs]
-	termbox.SetCell(x, y, border[0][0], fg, bg)
-	termbox.SetCell(x+w-1, y, border[0][4], fg, bg)
-	termbox.SetCell(x, y+h-1, border[4][0], fg, bg)
-	termbox.SetCell(x+w-1, y+h-1, border[4][4], fg, bg)
+	screen.SetCell(x, y, border[0][0], fg, bg)
+	screen.SetCell(x+w-1, y, border[0][4], fg, bg)
+	screen.SetCell(x, y+h-1, border[4][0], fg, bg)
+	screen.SetCell(x+w-1, y+h-1, border[4][4], fg, bg)
 
 	for i := x + 1; i < x+w-1; i++ {
-		termbox.SetCell(i, y, border[0][1], fg, bg)
-		termbox.SetCell(i, y+h-1, border[4][1], fg, bg)
+		screen.SetCell(i, y, border[0][1], fg, bg)
+		screen.SetCell(i, y+h-1, border[4][1], fg, bg)
 	}
 
 	for i := y + 1; i < y+h-1; i++ {
-		termbox.SetCell(x, i, border[1][0], fg, bg)
-		termbox.SetCell(x+w-1, i, border[1][4], fg, bg)
+		screen.SetCell(x, i, border[1][0], fg, bg)
+		screen.SetCell(x+w-1, i, border[1][4], fg, bg)
 	}
 }
 
-func FillBox(x, y, w, h int, fg termbox.Attribute, bg termbox.Attribute, bs BorderStyle) {
-	DrawBox(x, y, w, h, fg, bg, bs)
+func FillBox(screen Screen, x, y, w, h int, fg termbox.Attribute, bg termbox.Attribute, bs BorderStyle) {
+	DrawBox(screen, x, y, w, h, fg, bg, bs)
 	for j := y + 1; j < y+h-1; j++ {
 		for i := x + 1; i < x+w-1; i++ {
-			termbox.SetCell(i, j, ' ', fg, bg)
+			screen.SetCell(i, j, ' ', fg, bg)
 		}
 	}
 }
 
-func Puts(x, y int, s string, fg, bg termbox.Attribute) {
+func Puts(screen Screen, x, y int, s string, fg, bg termbox.Attribute) {
 	p := 0
 	for p < len(s) {
 		r, w := utf8.DecodeRune([]byte(s[p:]))
-		termbox.SetCell(x, y, r, fg, bg)
+		screen.SetCell(x, y, r, fg, bg)
 		x++
 		p += w
 	}
@@ -109,10 +109,17 @@ func max(i1, i2 int) int {
 	return i2
 }
 
-func FileDialog(title string) (string, bool) {
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+func FileDialog(screen Screen, title string) (string, bool) {
 	label := "Filename: "
 	editW := 50
-	termW, termH := termbox.Size()
+	termW, termH := screen.Size()
 	h := 4
 	w := min(termW, 4+len(label)+editW)
 
@@ -120,33 +127,33 @@ func FileDialog(title string) (string, bool) {
 	py := (termH - h) / 2
 
 	// Save background
-	buf := saveBlock(px, py, w, h)
-	savedCrsrX, savedCrsrY := termbox.GetCursor()
+	buf := saveBlock(screen, px, py, w, h)
+	savedCrsrX, savedCrsrY := screen.Cursor()
 
-	FillBox(px, py, w, h, ColLightCyan, ColBlue, BorderStyle_Double)
+	FillBox(screen, px, py, w, h, ColLightCyan, ColBlue, BorderStyle_Double)
 
-	Puts(px+5, py+2, "<Esc> to cancel", ColLightBlue, ColBlue)
+	Puts(screen, px+5, py+2, "<Esc> to cancel", ColLightBlue, ColBlue)
 	s := "<Enter> to confirm"
-	Puts(px+w-5-len(s), py+2, s, ColLightBlue, ColBlue)
+	Puts(screen, px+w-5-len(s), py+2, s, ColLightBlue, ColBlue)
 
-	Puts(px+int((w-len(title)+2)/2), py, " "+title+" ", ColWhite, ColBlue)
-	Puts(px+2, py+1, label, ColWhite, ColBlue)
-	termbox.Flush()
+	Puts(screen, px+int((w-len(title)+2)/2), py, " "+title+" ", ColWhite, ColBlue)
+	Puts(screen, px+2, py+1, label, ColWhite, ColBlue)
+	screen.Flush()
 
-	editField := NewEditField(px+2+len(label), py+1, editW, ColWhite, ColBlack)
+	editField := NewEditField(screen, px+2+len(label), py+1, editW, ColWhite, ColBlack)
 	filename, ok := editField.Run()
 
 	restoreBlock(buf)
-	termbox.SetCursor(savedCrsrX, savedCrsrY)
-	termbox.Flush()
+	screen.SetCursor(savedCrsrX, savedCrsrY)
+	screen.Flush()
 
 	return filename, ok
 }
 
-func YesNoCancelDialog(title, message string) (res bool, valid bool) {
+func YesNoCancelDialog(screen Screen, title, message string) (res bool, valid bool) {
 	buttons := "<Y> or <N>. <Esc> to cancel"
 	textW := max(max(len(buttons), len(title)), len(message))
-	termW, termH := termbox.Size()
+	termW, termH := screen.Size()
 	h := 5
 	w := min(termW, 4+textW)
 
@@ -154,21 +161,19 @@ func YesNoCancelDialog(title, message string) (res bool, valid bool) {
 	py := (termH - h) / 2
 
 	// Save background
-	buf := saveBlock(px, py, w, h)
-	savedCrsrX, savedCrsrY := termbox.GetCursor()
+	buf := saveBlock(screen, px, py, w, h)
+	savedCrsrX, savedCrsrY := screen.Cursor()
 
-	FillBox(px, py, w, h, ColLightCyan, ColBlue, BorderStyle_Double)
+	FillBox(screen, px, py, w, h, ColLightCyan, ColBlue, BorderStyle_Double)
 
-	Puts(px+int((w-len(title))/2), py, " "+title+" ", ColWhite, ColBlue)
-	Puts(px+int((w-len(message))/2), py+1, message, ColWhite, ColBlue)
-	Puts(px+int((w-len(buttons))/2), py+3, buttons, ColLightBlue, ColBlue)
-	termbox.Flush()
+	Puts(screen, px+int((w-len(title))/2), py, " "+title+" ", ColWhite, ColBlue)
+	Puts(screen, px+int((w-len(message))/2), py+1, message, ColWhite, ColBlue)
+	Puts(screen, px+int((w-len(buttons))/2), py+3, buttons, ColLightBlue, ColBlue)
+	screen.Flush()
 
 	quit := false
 	for !quit {
-		data := make([]byte, 30)
-		termbox.PollRawEvent(data)
-		ev := termbox.ParseEvent(data)
+		ev := screen.PollEvent()
 		switch ev.Type {
 		case termbox.EventKey:
 			switch {
@@ -190,16 +195,16 @@ func YesNoCancelDialog(title, message string) (res bool, valid bool) {
 	}
 
 	restoreBlock(buf)
-	termbox.SetCursor(savedCrsrX, savedCrsrY)
-	termbox.Flush()
+	screen.SetCursor(savedCrsrX, savedCrsrY)
+	screen.Flush()
 
 	return
 }
 
-func ErrorDialog(message string) {
+func ErrorDialog(screen Screen, message string) {
 	title := " Somethings's gone wrong... "
 	buttons := "<Return>"
-	termW, termH := termbox.Size()
+	termW, termH := screen.Size()
 	h := 6
 	w := min(termW, 4+max(len(title), len(message)))
 
@@ -207,33 +212,168 @@ func ErrorDialog(message string) {
 	py := (termH - h) / 2
 
 	// Save background
-	buf := saveBlock(px, py, w, h)
-	savedCrsrX, savedCrsrY := termbox.GetCursor()
+	buf := saveBlock(screen, px, py, w, h)
+	savedCrsrX, savedCrsrY := screen.Cursor()
+
+	FillBox(screen, px, py, w, h, ColLightRed, ColRed, BorderStyle_Double)
+	Puts(screen, px+int((w-len(title))/2), py, " "+title+" ", ColWhite, ColRed)
+	Puts(screen, px+int((w-len(message))/2), py+2, message, ColYellow, ColRed)
+	Puts(screen, px+int((w-len(buttons))/2), py+4, buttons, ColWhite, ColRed)
+	screen.Flush()
+
+	quit := false
+	for !quit {
+		ev := screen.PollEvent()
+		switch ev.Type {
+		case termbox.EventKey:
+			switch {
+			case ev.Mod == termbox.ModAlt && ev.Key == 0 && ev.Ch == 0:
+				// Only ESC pressed, nothing else
+				quit = true
+			case ev.Key == termbox.KeyEnter:
+				quit = true
+			}
+		}
+	}
+
+	restoreBlock(buf)
+	screen.SetCursor(savedCrsrX, savedCrsrY)
+	screen.Flush()
+}
+
+func ColorDialog(screen Screen, fg, bg termbox.Attribute) (newFg, newBg termbox.Attribute, ok bool) {
+	title := "Colors"
+	sample := " Draw like it's the nineties "
+	buttons := "<Tab> fg/bg, <Esc> to cancel, <Enter> to confirm"
+	labels := []string{"Foreground: ", "Background: "}
+	swatchW := 2
+	termW, termH := screen.Size()
+	h := 9
+	w := min(termW, 4+max(len(buttons), len(labels[0])+len(Palette)*swatchW))
+
+	px := (termW - w) / 2
+	py := (termH - h) / 2
+
+	// Save background
+	buf := saveBlock(screen, px, py, w, h)
+	savedCrsrX, savedCrsrY := screen.Cursor()
+	screen.HideCursor()
+
+	sel := []int{max(paletteIndex(fg), 0), max(paletteIndex(bg), 0)}
+	row := 0
+
+	quit := false
+	for !quit {
+		FillBox(screen, px, py, w, h, ColLightCyan, ColBlue, BorderStyle_Double)
+		Puts(screen, px+int((w-len(title))/2), py, " "+title+" ", ColWhite, ColBlue)
+		for i, l := range labels {
+			y := py + 1 + 2*i
+			labelFg := ColLightBlue
+			if i == row {
+				labelFg = ColWhite
+			}
+			Puts(screen, px+2, y, l, labelFg, ColBlue)
+			for j, col := range Palette {
+				x := px + 2 + len(l) + j*swatchW
+				Puts(screen, x, y, "██", col, ColBlue)
+				if j == sel[i] {
+					Puts(screen, x, y+1, "▀▀", labelFg, ColBlue)
+				}
+			}
+		}
+		Puts(screen, px+int((w-len(sample))/2), py+5, sample, Palette[sel[0]], Palette[sel[1]])
+		Puts(screen, px+int((w-len(buttons))/2), py+7, buttons, ColLightBlue, ColBlue)
+		screen.Flush()
+
+		ev := screen.PollEvent()
+		switch ev.Type {
+		case termbox.EventKey:
+			switch {
+			case ev.Mod == termbox.ModAlt && ev.Key == 0 && ev.Ch == 0:
+				// Only ESC pressed, nothing else
+				ok = false
+				quit = true
+			case ev.Key == termbox.KeyEnter:
+				ok = true
+				quit = true
+			case ev.Key == termbox.KeyTab || ev.Key == termbox.KeyArrowUp || ev.Key == termbox.KeyArrowDown:
+				row = 1 - row
+			case ev.Key == termbox.KeyArrowLeft:
+				sel[row] = (sel[row] + len(Palette) - 1) % len(Palette)
+			case ev.Key == termbox.KeyArrowRight:
+				sel[row] = (sel[row] + 1) % len(Palette)
+			}
+		}
+	}
 
-	FillBox(px, py, w, h, ColLightRed, ColRed, BorderStyle_Double)
-	Puts(px+int((w-len(title))/2), py, " "+title+" ", ColWhite, ColRed)
-	Puts(px+int((w-len(message))/2), py+2, message, ColYellow, ColRed)
-	Puts(px+int((w-len(buttons))/2), py+4, buttons, ColWhite, ColRed)
-	termbox.Flush()
+	restoreBlock(buf)
+	screen.SetCursor(savedCrsrX, savedCrsrY)
+	screen.Flush()
 
+	if !ok {
+		return fg, bg, false
+	}
+	return Palette[sel[0]], Palette[sel[1]], true
+}
+
+// ChoiceDialog lets the user pick one of the options. It returns the index
+// of the chosen option.
+func ChoiceDialog(screen Screen, title string, options []string) (int, bool) {
+	buttons := "<Esc> to cancel, <Enter> to confirm"
+	textW := max(len(buttons), len(title)+2)
+	for _, o := range options {
+		textW = max(textW, utf8.RuneCountInString(o)+2)
+	}
+	termW, termH := screen.Size()
+	h := len(options) + 4
+	w := min(termW, 4+textW)
+
+	px := (termW - w) / 2
+	py := (termH - h) / 2
+
+	// Save background
+	buf := saveBlock(screen, px, py, w, h)
+	savedCrsrX, savedCrsrY := screen.Cursor()
+	screen.HideCursor()
+
+	sel := 0
+	ok := false
 	quit := false
 	for !quit {
-		data := make([]byte, 30)
-		termbox.PollRawEvent(data)
-		ev := termbox.ParseEvent(data)
+		FillBox(screen, px, py, w, h, ColLightCyan, ColBlue, BorderStyle_Double)
+		Puts(screen, px+int((w-len(title))/2), py, " "+title+" ", ColWhite, ColBlue)
+		for i, o := range options {
+			fg, bg := ColWhite, ColBlue
+			if i == sel {
+				fg, bg = ColBlue, ColLightCyan
+			}
+			Puts(screen, px+2, py+1+i, " "+o+strings.Repeat(" ", textW-2-utf8.RuneCountInString(o))+" ", fg, bg)
+		}
+		Puts(screen, px+int((w-len(buttons))/2), py+h-2, buttons, ColLightBlue, ColBlue)
+		screen.Flush()
+
+		ev := screen.PollEvent()
 		switch ev.Type {
 		case termbox.EventKey:
 			switch {
 			case ev.Mod == termbox.ModAlt && ev.Key == 0 && ev.Ch == 0:
 				// Only ESC pressed, nothing else
+				ok = false
 				quit = true
 			case ev.Key == termbox.KeyEnter:
+				ok = true
 				quit = true
+			case ev.Key == termbox.KeyArrowUp:
+				sel = (sel + len(options) - 1) % len(options)
+			case ev.Key == termbox.KeyArrowDown:
+				sel = (sel + 1) % len(options)
 			}
 		}
 	}
 
 	restoreBlock(buf)
-	termbox.SetCursor(savedCrsrX, savedCrsrY)
-	termbox.Flush()
+	screen.SetCursor(savedCrsrX, savedCrsrY)
+	screen.Flush()
+
+	return sel, ok
 }
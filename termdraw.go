@@ -19,18 +19,59 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/asig/termbox-go"
 
 	"github.com/asig/termdraw/pkg/termdraw"
 )
 
+type exportFormat struct {
+	id     string // as used on the command line
+	name   string
+	export func(w io.Writer) error
+}
+
+var exportFormats = []exportFormat{
+	{"ansi", "ANSI text, 24-bit colors", func(w io.Writer) error { return canvas.ExportANSI(w, termdraw.ColorModeTrue) }},
+	{"ansi256", "ANSI text, 256 colors", func(w io.Writer) error { return canvas.ExportANSI(w, termdraw.ColorMode256) }},
+	{"ansi16", "ANSI text, 16 colors", func(w io.Writer) error { return canvas.ExportANSI(w, termdraw.ColorMode16) }},
+	{"html", "HTML page", func(w io.Writer) error {
+		return canvas.ExportHTML(w, termdraw.HTMLOptions{Standalone: true})
+	}},
+	{"html-classes", "HTML page with CSS classes", func(w io.Writer) error {
+		return canvas.ExportHTML(w, termdraw.HTMLOptions{Standalone: true, Classes: true})
+	}},
+	{"svg", "SVG image", func(w io.Writer) error { return canvas.ExportSVG(w) }},
+	{"png", "PNG image", func(w io.Writer) error { return canvas.ExportPNG(w) }},
+}
+
+type tool int
+
+const (
+	toolNone tool = iota
+	toolBox
+	toolLine
+	toolCircle
+)
+
+type pen int
+
+const (
+	penUp pen = iota
+	penDraw
+	penErase
+)
+
 var (
+	screen termdraw.Screen
 	termW  int
 	termH  int
 	canvas *termdraw.Canvas
@@ -39,6 +80,36 @@ var (
 	curFilename    string
 	insert         bool
 	dirty          bool
+
+	// Set while a border is drawn with the cursor keys, so that the whole
+	// stroke can be undone in one go.
+	stroking bool
+
+	clipboard *termdraw.Clipboard
+	// Set while the selected block is moved with the cursor keys
+	moving bool
+
+	// Tool waiting for its second corner. The first corner is the
+	// selection anchor.
+	pendingTool tool
+
+	curRoute    termdraw.Route
+	curStartCap termdraw.Cap
+	curEndCap   termdraw.Cap
+
+	// In paint mode, moving the cursor recolors cells instead of drawing
+	painting bool
+	// In erase mode, moving the cursor removes the borders along its path
+	erasing bool
+
+	// In pixel mode, the cursor moves over the pixels of the cells
+	pixels    bool
+	pixelMode termdraw.PixelMode
+	pixelPos  termdraw.Pos
+	pixelPen  pen
+	// Pixel tool waiting for its second point, and its first one
+	pixelTool   tool
+	pixelAnchor termdraw.Pos
 )
 
 func showWelcome() {
@@ -63,7 +134,7 @@ func showWelcome() {
 			{{"                               ", lr, bg}},
 		},
 	}
-	t.Show()
+	t.Show(screen)
 }
 
 func showHelp() {
@@ -71,33 +142,66 @@ func showHelp() {
 	w := termdraw.ColWhite | termbox.AttrBold
 	yb := y | termbox.AttrBold
 	bg := termdraw.ColBrown
+	text := func(s string) termdraw.Segment { return termdraw.Segment{S: s, Fg: y, Bg: bg} }
+	key := func(s string) termdraw.Segment { return termdraw.Segment{S: s, Fg: w, Bg: bg} }
+
+	content := []termdraw.Line{
+		{{"                            * * * H E L P * * *", yb, bg}},
+		{text("Termdraw is focussed on drawing Unicode-based borders in text files. To")},
+		{text("do so, press "), key("Ctrl-B"), text(" to pick the border style, and then use the "), key("Cursor")},
+		{key("keys"), text(" to draw the border; "), key("Home"), text(", "), key("PgUp"), text(", "), key("End"), text(" and "), key("PgDn"), text(" draw diagonally.")},
+		{text("Besides that, it pretty much works like a regular text editor.")},
+		{},
+		{text("In pixel mode, "), key("Enter"), text(" toggles a pixel, "), key("Space"), text(" and "), key("Del"), text(" toggle pen and eraser,")},
+		{key("Ctrl-L"), text(", "), key("Ctrl-R"), text(" and "), key("Alt-O"), text(" draw lines, boxes and circles, "), key("Ctrl-F"), text(" fills.")},
+		{},
+	}
+	keys := [][2]string{
+		{"Ctrl-B", "Border style (Alt-B: back)"},
+		{"Ctrl-X", "Quit"},
+		{"Ctrl-H", "Show this help"},
+		{"Ctrl-O", "Load text or document"},
+		{"Ctrl-S", "Save (" + termdraw.DocumentExt + ": with colors)"},
+		{"Ctrl-E", "Export, e.g. as HTML or PNG"},
+		{"Ctrl-I", "Insert a line"},
+		{"Ctrl-D", "Delete current line"},
+		{"Ctrl-Z", "Undo"},
+		{"Ctrl-Y", "Redo"},
+		{"Ctrl-K", "Select block (Esc: cancel)"},
+		{"Ctrl-C", "Copy block"},
+		{"Ctrl-W", "Cut block"},
+		{"Ctrl-V", "Paste block"},
+		{"Alt-V", "Paste, skipping spaces"},
+		{"Alt-M", "Move block with cursor keys"},
+		{"Alt-C", "Convert borders from/to ASCII"},
+		{"Alt-S", "Restyle borders"},
+		{"Ctrl-R", "Box: press at both corners"},
+		{"Ctrl-L", "Line: press at both ends"},
+		{"Alt-R", "Select line routing"},
+		{"Alt-A", "Arrowhead (Alt-Shift-A: start)"},
+		{"Ctrl-P", "Select colors"},
+		{"Alt-P", "Paint mode: recolor cells"},
+		{"Alt-E", "Erase mode: remove borders"},
+		{"Alt-X", "Pixels: off, 1x2, 2x2, Braille"},
+	}
+	rows := (len(keys) + 1) / 2
+	for i := 0; i < rows; i++ {
+		var l termdraw.Line
+		for _, k := range []int{i, i + rows} {
+			if k < len(keys) {
+				l = append(l, key(fmt.Sprintf("%-7s", keys[k][0])), text(fmt.Sprintf("%-31s", keys[k][1])))
+			}
+		}
+		content = append(content, l)
+	}
+
 	t := &termdraw.TextCard{
-		Fg: y,
-		Bg: bg,
-		Bs: termdraw.BorderStyle_Rounded,
-		Content: []termdraw.Line{
-			{{"                      * * * H E L P * * *                      ", yb, bg}},
-			{{"", y, bg}},
-			{{"Termdraw is focussed on drawing Unicode-based borders in text", y, bg}},
-			{{"files. To do so, press ", y, bg}, {"Ctrl-B", w, bg}, {" to pick the border style, and then", y, bg}},
-			{{"use the ", y, bg}, {"Cursor keys", w, bg}, {" to draw the border.", y, bg}},
-			{{"", y, bg}},
-			{{"Besides that, it pretty  much works like a regular text editor.", y, bg}},
-			{{"", y, bg}},
-			{{"Commands", y, bg}},
-			{{"────────", y, bg}},
-			{{"Ctrl-B ", w, bg}, {"Select border style", y, bg}},
-			{{"Ctrl-Q ", w, bg}, {"Quit", y, bg}},
-			{{"", y, bg}},
-			{{"Ctrl-H ", w, bg}, {"Show this dialog", y, bg}},
-			{{"", y, bg}},
-			{{"Ctrl-O ", w, bg}, {"Load a text file", y, bg}},
-			{{"Ctrl-S ", w, bg}, {"Save as a text file", y, bg}},
-			{{"Ctrl-I ", w, bg}, {"Insert a line", y, bg}},
-			{{"Ctrl-D ", w, bg}, {"Delete current line", y, bg}},
-		},
+		Fg:      y,
+		Bg:      bg,
+		Bs:      termdraw.BorderStyle_Rounded,
+		Content: content,
 	}
-	t.Show()
+	t.Show(screen)
 }
 
 func drawStatusbar() {
@@ -106,7 +210,7 @@ func drawStatusbar() {
 	if insert {
 		ins = "INS"
 	}
-	status := fmt.Sprintf(" Pos: %d/%d | %s | Border style: %s ", p.X, p.Y, ins, curBorderStyle)
+	var status string
 	if curFilename != "" || dirty {
 		var filepart string
 		if dirty {
@@ -115,36 +219,230 @@ func drawStatusbar() {
 		if curFilename != "" {
 			filepart += curFilename
 		}
-		status = " " + filepart + " |" + status
+		status = " " + filepart + " |"
+	}
+	status += fmt.Sprintf(" Pos: %d/%d | %s | Border style: %s | Color: ", p.X, p.Y, ins, curBorderStyle)
+	// The color sample is drawn over the placeholder later
+	colorX := utf8.RuneCountInString(status)
+	status += "    "
+	if painting {
+		status += "(painting) "
+	}
+	if erasing {
+		status += "(erasing) "
+	}
+	if pixels {
+		status += fmt.Sprintf("| Pixels %s: %d/%d ", pixelMode, pixelPos.X, pixelPos.Y)
+		switch pixelPen {
+		case penDraw:
+			status += "(pen) "
+		case penErase:
+			status += "(eraser) "
+		}
+		switch pixelTool {
+		case toolBox:
+			status += fmt.Sprintf("| Box from %d/%d ", pixelAnchor.X, pixelAnchor.Y)
+		case toolLine:
+			status += fmt.Sprintf("| Line from %d/%d ", pixelAnchor.X, pixelAnchor.Y)
+		case toolCircle:
+			status += fmt.Sprintf("| Circle around %d/%d ", pixelAnchor.X, pixelAnchor.Y)
+		}
+	}
+	status += fmt.Sprintf("| Undo/Redo: %d/%d ", canvas.UndoDepth(), canvas.RedoDepth())
+	if sel, ok := canvas.Selection(); ok {
+		switch pendingTool {
+		case toolBox:
+			status += fmt.Sprintf("| Box: %dx%d ", sel.W, sel.H)
+		case toolLine:
+			status += fmt.Sprintf("| Line: %s %c-%c ", curRoute, curStartCap.Rune(termdraw.DirLeft), curEndCap.Rune(termdraw.DirRight))
+		default:
+			status += fmt.Sprintf("| Block: %dx%d ", sel.W, sel.H)
+		}
+		if moving {
+			status += "(moving) "
+		}
 	}
-	if len(status) < termW {
-		status = status + strings.Repeat(" ", termW-len(status))
+	if l := utf8.RuneCountInString(status); l < termW {
+		status = status + strings.Repeat(" ", termW-l)
 	}
 
-	termdraw.Puts(0, termH-1, status, termdraw.ColCyan, termdraw.ColBlue)
+	termdraw.Puts(screen, 0, termH-1, status, termdraw.ColCyan, termdraw.ColBlue)
+	fg, bg := canvas.Colors()
+	termdraw.Puts(screen, colorX, termH-1, " Aa ", fg, bg)
 }
 
 func handleMove(dir termdraw.Direction) {
+	if moving {
+		if canvas.MoveSelection(dir) {
+			dirty = true
+		}
+		return
+	}
+	if _, ok := canvas.Selection(); ok {
+		// Extend the selection
+		canvas.Move(dir)
+		return
+	}
+	if painting {
+		if !stroking {
+			canvas.BeginGroup()
+			stroking = true
+		}
+		oldPos, newPos := canvas.Move(dir)
+		canvas.Paint(oldPos)
+		canvas.Paint(newPos)
+		dirty = true
+		return
+	}
+	if erasing {
+		if !stroking {
+			canvas.BeginGroup()
+			stroking = true
+		}
+		oldPos, newPos := canvas.Move(dir)
+		if oldPos != newPos {
+			canvas.Erase(oldPos, dir)
+			dirty = true
+		}
+		return
+	}
+	if curBorderStyle != termdraw.BorderStyle_None && !stroking {
+		canvas.BeginGroup()
+		stroking = true
+	}
 	oldPos, newPos := canvas.Move(dir)
 	if curBorderStyle == termdraw.BorderStyle_None {
 		return
 	}
 	if oldPos != newPos {
-		// Leaving the current tile in the direction we're moving
-		canvas.SetTile(oldPos, canvas.Tile(oldPos).WithDir(dir, curBorderStyle))
-		// Entering the new tile from the inverse diurection
-		canvas.SetTile(newPos, canvas.Tile(newPos).WithDir(dir.Inverse(), curBorderStyle))
+		canvas.Stroke(oldPos, dir, curBorderStyle)
+		dirty = true
+	}
+}
+
+// handlePixelMode switches from text to 1x2 and 2x2 pixels, to 2x4 Braille
+// dots, and back.
+func handlePixelMode() {
+	switch {
+	case !pixels:
+		pixels, pixelMode = true, termdraw.PixelHalf
+	case pixelMode == termdraw.PixelHalf:
+		pixelMode = termdraw.PixelQuadrant
+	case pixelMode == termdraw.PixelQuadrant:
+		pixelMode = termdraw.PixelBraille
+	default:
+		pixels = false
+	}
+	pixelPen, pixelTool = penUp, toolNone
+	p := canvas.Pos()
+	w, h := pixelMode.Size()
+	pixelPos = termdraw.Pos{X: p.X * w, Y: p.Y * h}
+}
+
+// applyPen draws or erases the pixel under the cursor, depending on the
+// pen.
+func applyPen() {
+	if pixelPen == penUp {
+		return
+	}
+	if !stroking {
+		canvas.BeginGroup()
+		stroking = true
+	}
+	if pixelPen == penDraw {
+		fg, _ := canvas.Colors()
+		canvas.SetPixel(pixelMode, pixelPos, fg)
+	} else {
+		canvas.ClearPixel(pixelMode, pixelPos)
+	}
+	dirty = true
+}
+
+func togglePen(p pen) {
+	if pixelPen == p {
+		pixelPen = penUp
+		return
+	}
+	pixelPen = p
+	applyPen()
+}
+
+func handlePixelTool(t tool) {
+	if pixelTool != t {
+		pixelTool = t
+		pixelAnchor = pixelPos
+		return
+	}
+	fg, _ := canvas.Colors()
+	switch t {
+	case toolBox:
+		canvas.PixelRect(pixelMode, pixelAnchor, pixelPos, fg)
+	case toolLine:
+		canvas.PixelLine(pixelMode, pixelAnchor, pixelPos, fg)
+	case toolCircle:
+		canvas.PixelCircle(pixelMode, pixelAnchor, pixelPos, fg)
+	}
+	pixelTool = toolNone
+	dirty = true
+}
+
+// handlePixelKey handles the keys that work differently in pixel mode. It
+// returns false for all other keys.
+func handlePixelKey(ev termbox.Event) bool {
+	switch {
+	case isMoveKey(ev.Key):
+		n := pixelPos.Step(moveDirs[ev.Key])
+		if n.X < 0 || n.Y < 0 {
+			break
+		}
+		pixelPos = n
+		canvas.SetPos(pixelMode.Cell(n))
+		applyPen()
+	case ev.Key == termbox.KeyEnter:
+		fg, _ := canvas.Colors()
+		canvas.TogglePixel(pixelMode, pixelPos, fg)
+		dirty = true
+	case ev.Key == termbox.KeySpace:
+		togglePen(penDraw)
+	case ev.Key == termbox.KeyDelete:
+		togglePen(penErase)
+	case ev.Key == termbox.KeyCtrlR:
+		handlePixelTool(toolBox)
+	case ev.Key == termbox.KeyCtrlL:
+		handlePixelTool(toolLine)
+	case isAlt(ev, 'o'):
+		handlePixelTool(toolCircle)
+	case ev.Key == termbox.KeyCtrlF:
+		fg, _ := canvas.Colors()
+		canvas.PixelFill(pixelMode, pixelPos, fg)
 		dirty = true
+	case isAlt(ev, 0):
+		pixelPen, pixelTool = penUp, toolNone
+		return false
+	case unicode.IsPrint(ev.Ch), ev.Key == termbox.KeyBackspace, ev.Key == termbox.KeyBackspace2, ev.Key == termbox.KeyInsert:
+		// No typing in pixel mode
+	default:
+		return false
+	}
+	return true
+}
+
+func endStroke() {
+	if stroking {
+		canvas.EndGroup()
+		stroking = false
 	}
 }
 
 func handleChar(ch rune) {
 	p := canvas.Pos()
+	canvas.BeginGroup()
 	if insert {
 		canvas.Insert(p)
 	}
 	canvas.SetRune(p, ch)
 	canvas.Move(termdraw.DirRight)
+	canvas.EndGroup()
 	dirty = true
 }
 
@@ -153,8 +451,10 @@ func handleBackspace() {
 	if p.X == 0 {
 		return
 	}
+	canvas.BeginGroup()
 	canvas.Delete(termdraw.Pos{p.X - 1, p.Y})
 	canvas.Move(termdraw.DirLeft)
+	canvas.EndGroup()
 	dirty = true
 }
 
@@ -184,7 +484,211 @@ func handleDeleteLine() {
 	dirty = true
 }
 
-func saveCanvas() {
+// drawingStyle returns the border style to use for the shape tools.
+func drawingStyle() termdraw.BorderStyle {
+	if curBorderStyle == termdraw.BorderStyle_None {
+		return termdraw.BorderStyle_Light
+	}
+	return curBorderStyle
+}
+
+func handleTool(t tool) {
+	sel, ok := canvas.Selection()
+	if pendingTool != t || !ok {
+		canvas.StartSelection()
+		pendingTool = t
+		return
+	}
+	switch t {
+	case toolBox:
+		canvas.DrawBox(sel, drawingStyle())
+	case toolLine:
+		canvas.DrawConnector(canvas.SelectionAnchor(), canvas.Pos(), curRoute, drawingStyle(), curStartCap, curEndCap)
+	}
+	cancelTool()
+	dirty = true
+}
+
+func cancelTool() {
+	pendingTool = toolNone
+	canvas.ClearSelection()
+}
+
+func handleMark() {
+	if _, ok := canvas.Selection(); ok {
+		cancelTool()
+		return
+	}
+	pendingTool = toolNone
+	canvas.StartSelection()
+}
+
+func handleCopy() {
+	sel, ok := canvas.Selection()
+	if !ok {
+		return
+	}
+	clipboard = canvas.Copy(sel)
+	cancelTool()
+}
+
+func handleCut() {
+	sel, ok := canvas.Selection()
+	if !ok {
+		return
+	}
+	clipboard = canvas.Cut(sel)
+	cancelTool()
+	dirty = true
+}
+
+// handleConvert converts the borders of the selection, or of the whole
+// drawing, to or from ASCII.
+func handleConvert() {
+	names := []string{"To ASCII"}
+	var styles []termdraw.BorderStyle
+	for bs := termdraw.BorderStyle_Light; bs <= termdraw.BorderStyle_Max; bs++ {
+		if bs != termdraw.BorderStyle_ASCII {
+			names = append(names, "From ASCII to "+bs.String())
+			styles = append(styles, bs)
+		}
+	}
+	i, ok := termdraw.ChoiceDialog(screen, "Convert Borders", names)
+	if !ok {
+		return
+	}
+	r, ok := canvas.Selection()
+	if !ok {
+		w, h := canvas.Extent()
+		r = termdraw.Rect{W: w, H: h}
+	}
+	if i == 0 {
+		canvas.ConvertToASCII(r)
+	} else {
+		canvas.ConvertFromASCII(r, styles[i-1])
+	}
+	cancelTool()
+	dirty = true
+}
+
+// handleRestyle changes the borders of the selection, of the border under
+// the cursor, or of the whole drawing to the current border style.
+func handleRestyle() {
+	if curBorderStyle == termdraw.BorderStyle_None {
+		termdraw.ErrorDialog(screen, "Select the new border style with Ctrl-B first")
+		return
+	}
+
+	sel, hasSel := canvas.Selection()
+	var scopes []string
+	if hasSel {
+		scopes = append(scopes, "Selection")
+	} else {
+		scopes = append(scopes, "Border under cursor")
+	}
+	scopes = append(scopes, "Whole drawing")
+	scope, ok := termdraw.ChoiceDialog(screen, "Restyle to "+curBorderStyle.String(), scopes)
+	if !ok {
+		return
+	}
+
+	names := []string{"All styles"}
+	styles := []termdraw.BorderStyle{termdraw.BorderStyle_None}
+	for bs := termdraw.BorderStyle_Light; bs <= termdraw.BorderStyle_Max; bs++ {
+		if bs != curBorderStyle {
+			names = append(names, bs.String())
+			styles = append(styles, bs)
+		}
+	}
+	i, ok := termdraw.ChoiceDialog(screen, "Restyle from", names)
+	if !ok {
+		return
+	}
+	from := styles[i]
+
+	switch {
+	case scope == 0 && hasSel:
+		canvas.Restyle(sel, from, curBorderStyle)
+	case scope == 0:
+		canvas.RestyleComponent(canvas.Pos(), from, curBorderStyle)
+	default:
+		w, h := canvas.Extent()
+		canvas.Restyle(termdraw.Rect{W: w, H: h}, from, curBorderStyle)
+	}
+	cancelTool()
+	dirty = true
+}
+
+func handlePaste(transparent bool) {
+	if clipboard == nil {
+		return
+	}
+	canvas.Paste(canvas.Pos(), clipboard, transparent)
+	dirty = true
+}
+
+func handleStartMove() {
+	if _, ok := canvas.Selection(); !ok {
+		return
+	}
+	canvas.BeginGroup()
+	moving = true
+}
+
+func endMove() {
+	if moving {
+		canvas.EndGroup()
+		canvas.EndMove()
+		moving = false
+	}
+}
+
+func handleColors() {
+	fg, bg := canvas.Colors()
+	fg, bg, ok := termdraw.ColorDialog(screen, fg, bg)
+	if ok {
+		canvas.SetColors(fg, bg)
+	}
+}
+
+func handleExport() {
+	var names []string
+	for _, f := range exportFormats {
+		names = append(names, f.name)
+	}
+	i, ok := termdraw.ChoiceDialog(screen, "Export", names)
+	if !ok {
+		return
+	}
+	filename, ok := termdraw.FileDialog(screen, "Export File")
+	if !ok {
+		return
+	}
+	var buf bytes.Buffer
+	err := exportFormats[i].export(&buf)
+	if err == nil {
+		err = ioutil.WriteFile(filename, buf.Bytes(), 0644)
+	}
+	if err != nil {
+		termdraw.ErrorDialog(screen, err.Error())
+	}
+}
+
+func handleUndo() {
+	if canvas.Undo() {
+		dirty = true
+	}
+}
+
+func handleRedo() {
+	if canvas.Redo() {
+		dirty = true
+	}
+}
+
+// writeText writes the canvas as plain text, without trailing blanks and
+// empty lines.
+func writeText(w io.Writer) error {
 	text := canvas.AsText()
 	for i, _ := range text {
 		text[i] = strings.TrimRightFunc(text[i], unicode.IsSpace)
@@ -194,7 +698,46 @@ func saveCanvas() {
 		end--
 	}
 	text = text[:end]
-	ioutil.WriteFile(curFilename, []byte(strings.Join(text, "\n")), 0644)
+	_, err := io.WriteString(w, strings.Join(text, "\n"))
+	return err
+}
+
+// writeCanvas writes the canvas in the native format if filename has the
+// native extension, and as plain text otherwise.
+func writeCanvas(w io.Writer, filename string) error {
+	if strings.HasSuffix(filename, termdraw.DocumentExt) {
+		return canvas.WriteDocument(w)
+	}
+	return writeText(w)
+}
+
+func saveCanvas() error {
+	var buf bytes.Buffer
+	if err := writeCanvas(&buf, curFilename); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(curFilename, buf.Bytes(), 0644)
+}
+
+// readCanvas replaces the canvas' content with data, detecting its format.
+// filename is only used as a hint.
+func readCanvas(data []byte, filename string) error {
+	switch {
+	case termdraw.IsDocument(data):
+		if err := canvas.ReadDocument(bytes.NewReader(data)); err != nil {
+			return err
+		}
+	case termdraw.IsANSI(data):
+		canvas.ImportANSI(data, strings.HasSuffix(strings.ToLower(filename), ".ans"))
+	default:
+		lines := strings.Split(string(data), "\n")
+		for i, l := range lines {
+			lines[i] = strings.TrimSuffix(l, "\r")
+		}
+		canvas.SetText(lines)
+	}
+	canvas.ResetHistory()
+	return nil
 }
 
 func loadCanvas(filename string) error {
@@ -202,28 +745,30 @@ func loadCanvas(filename string) error {
 	if err != nil {
 		return err
 	}
-	lines := strings.Split(string(data), "\n")
-	canvas.SetText(lines)
-	return nil
+	return readCanvas(data, filename)
 }
 
 func handleSave() {
 	if curFilename == "" {
-		f, ok := termdraw.FileDialog("Save File")
+		f, ok := termdraw.FileDialog(screen, "Save File")
 		if !ok {
 			return
 		}
 		curFilename = f
 	}
 
-	saveCanvas()
+	err := saveCanvas()
+	if err != nil {
+		termdraw.ErrorDialog(screen, err.Error())
+		return
+	}
 
 	dirty = false
 }
 
 func handleLoad() {
 	if dirty {
-		res, valid := termdraw.YesNoCancelDialog("Save?", "Text is modified. Save it first?")
+		res, valid := termdraw.YesNoCancelDialog(screen, "Save?", "Text is modified. Save it first?")
 		if !valid {
 			return
 		}
@@ -231,13 +776,13 @@ func handleLoad() {
 			handleSave()
 		}
 	}
-	f, ok := termdraw.FileDialog("Open File")
+	f, ok := termdraw.FileDialog(screen, "Open File")
 	if !ok {
 		return
 	}
 	err := loadCanvas(f)
 	if err != nil {
-		termdraw.ErrorDialog(err.Error())
+		termdraw.ErrorDialog(screen, err.Error())
 		return
 	}
 
@@ -245,7 +790,7 @@ func handleLoad() {
 }
 
 func maybeSave() bool {
-	res, valid := termdraw.YesNoCancelDialog("Save?", "Text is modified. Save it?")
+	res, valid := termdraw.YesNoCancelDialog(screen, "Save?", "Text is modified. Save it?")
 	if !valid {
 		return false
 	}
@@ -255,20 +800,57 @@ func maybeSave() bool {
 	return true
 }
 
+// moveDirs maps the keys that move the cursor to their directions.
+var moveDirs = map[termbox.Key]termdraw.Direction{
+	termbox.KeyArrowUp:    termdraw.DirUp,
+	termbox.KeyArrowDown:  termdraw.DirDown,
+	termbox.KeyArrowLeft:  termdraw.DirLeft,
+	termbox.KeyArrowRight: termdraw.DirRight,
+	termbox.KeyHome:       termdraw.DirUpLeft,
+	termbox.KeyPgup:       termdraw.DirUpRight,
+	termbox.KeyEnd:        termdraw.DirDownLeft,
+	termbox.KeyPgdn:       termdraw.DirDownRight,
+}
+
+func isMoveKey(k termbox.Key) bool {
+	_, ok := moveDirs[k]
+	return ok
+}
+
+// isAlt checks whether ev is ch pressed together with Alt. With ch == 0, it
+// checks for a single Esc.
+func isAlt(ev termbox.Event, ch rune) bool {
+	return ev.Mod == termbox.ModAlt && ev.Key == 0 && ev.Ch == ch
+}
+
 func handleEvent(ev termbox.Event) (quit bool, helpShown bool) {
 	switch ev.Type {
 	case termbox.EventResize:
-		newW, newH := termbox.Size()
+		newW, newH := screen.Size()
 		deltaW := newW - termW
 		deltaH := newH - termH
 		s := fmt.Sprintf("old size: %d x %d; new size %d x %d,  deltaW = %d, deltaH = %d", termW, termH, newW, newH, deltaW, deltaH)
-		termdraw.Puts(10, 0, s, termbox.AttrBold|termbox.ColorWhite, termbox.ColorBlue)
+		termdraw.Puts(screen, 10, 0, s, termbox.AttrBold|termbox.ColorWhite, termbox.ColorBlue)
 		termW = newW
 		termH = newH
 		canvas.IncSize(deltaW, deltaH)
 
 	case termbox.EventKey:
+		if !isMoveKey(ev.Key) {
+			endStroke()
+			if moving {
+				// Any other key drops the block at its current position
+				endMove()
+				return quit, helpShown
+			}
+		}
+		if pixels && !moving && handlePixelKey(ev) {
+			return quit, helpShown
+		}
 		switch {
+		case isAlt(ev, 0):
+			// Only ESC pressed, nothing else
+			cancelTool()
 		case ev.Key == termbox.KeyCtrlX:
 			quit = true
 			if dirty {
@@ -276,33 +858,73 @@ func handleEvent(ev termbox.Event) (quit bool, helpShown bool) {
 			}
 		case ev.Key == termbox.KeyCtrlB:
 			curBorderStyle = curBorderStyle.Next()
+		case isAlt(ev, 'b'):
+			curBorderStyle = curBorderStyle.Prev()
 		case ev.Key == termbox.KeyCtrlH:
 			helpShown = true
 			showHelp()
-		case ev.Key == termbox.KeyArrowDown:
-			handleMove(termdraw.DirDown)
-		case ev.Key == termbox.KeyArrowUp:
-			handleMove(termdraw.DirUp)
-		case ev.Key == termbox.KeyArrowLeft:
-			handleMove(termdraw.DirLeft)
-		case ev.Key == termbox.KeyArrowRight:
-			handleMove(termdraw.DirRight)
+		case isMoveKey(ev.Key):
+			handleMove(moveDirs[ev.Key])
 		case ev.Key == termbox.KeyInsert:
 			insert = !insert
 		case ev.Key == termbox.KeyBackspace || ev.Key == termbox.KeyBackspace2:
 			handleBackspace()
 		case ev.Key == termbox.KeyDelete:
 			handleDelete()
+		case ev.Key == termbox.KeyEnter && pendingTool != toolNone:
+			handleTool(pendingTool)
 		case ev.Key == termbox.KeyEnter:
 			handleEnter()
 		case ev.Key == termbox.KeyCtrlS:
 			handleSave()
 		case ev.Key == termbox.KeyCtrlO:
 			handleLoad()
+		case ev.Key == termbox.KeyCtrlE:
+			handleExport()
 		case ev.Key == termbox.KeyCtrlI:
 			handleInsertLine()
 		case ev.Key == termbox.KeyCtrlD:
 			handleDeleteLine()
+		case ev.Key == termbox.KeyCtrlZ:
+			handleUndo()
+		case ev.Key == termbox.KeyCtrlY:
+			handleRedo()
+		case ev.Key == termbox.KeyCtrlK:
+			handleMark()
+		case ev.Key == termbox.KeyCtrlC:
+			handleCopy()
+		case ev.Key == termbox.KeyCtrlW:
+			handleCut()
+		case ev.Key == termbox.KeyCtrlV:
+			handlePaste(false)
+		case isAlt(ev, 'v'):
+			handlePaste(true)
+		case isAlt(ev, 'm'):
+			handleStartMove()
+		case isAlt(ev, 'c'):
+			handleConvert()
+		case isAlt(ev, 's'):
+			handleRestyle()
+		case ev.Key == termbox.KeyCtrlR:
+			handleTool(toolBox)
+		case ev.Key == termbox.KeyCtrlL:
+			handleTool(toolLine)
+		case isAlt(ev, 'r'):
+			curRoute = curRoute.Next()
+		case isAlt(ev, 'a'):
+			curEndCap = curEndCap.Next()
+		case isAlt(ev, 'A'):
+			curStartCap = curStartCap.Next()
+		case ev.Key == termbox.KeyCtrlP:
+			handleColors()
+		case isAlt(ev, 'p'):
+			painting = !painting
+			erasing = false
+		case isAlt(ev, 'e'):
+			erasing = !erasing
+			painting = false
+		case isAlt(ev, 'x'):
+			handlePixelMode()
 		case unicode.IsPrint(ev.Ch) || ev.Key == ' ':
 			if ev.Key == ' ' {
 				ev.Ch = ' '
@@ -321,6 +943,11 @@ func handleEvent(ev termbox.Event) (quit bool, helpShown bool) {
 }
 
 func main() {
+	code, edit, filename := runCLI(stdStreams, os.Args[1:])
+	if !edit {
+		os.Exit(code)
+	}
+
 	err := termbox.Init()
 	if err != nil {
 		panic(err)
@@ -330,44 +957,41 @@ func main() {
 	termbox.SetInputMode(termbox.InputAlt | termbox.InputMouse)
 	termbox.SetOutputMode(termbox.OutputRGB)
 
-	termW, termH = termbox.Size()
-	canvas = termdraw.NewCanvas(0, 0, termW, termH-1)
+	screen = termdraw.TermboxScreen{}
+	termW, termH = screen.Size()
+	canvas = termdraw.NewCanvas(0, 0, termW, termH-1, termdraw.WithScreen(screen))
 
 	curFilename = ""
 
 	drawStatusbar()
-	if len(os.Args) > 1 {
-		err = loadCanvas(os.Args[1])
+	if filename != "" {
+		err = loadCanvas(filename)
 		if err == nil {
-			curFilename = os.Args[1]
+			curFilename = filename
 		}
 	}
 	canvas.Draw()
 	if err != nil {
-		termdraw.ErrorDialog(err.Error())
+		termdraw.ErrorDialog(screen, err.Error())
 	} else {
 		showWelcome()
-		termbox.Flush()
+		screen.Flush()
 	}
 
 	curBorderStyle = termdraw.BorderStyle_None
 	dirty = false
 
-	eventBuf := make([]byte, 20)
 	quit := false
 	for !quit {
 		helpShown := false
 
-		ev := termbox.PollRawEvent(eventBuf)
-		if ev.Type == termbox.EventRaw {
-			ev = termbox.ParseEvent(eventBuf)
-		}
+		ev := screen.PollEvent()
 		quit, helpShown = handleEvent(ev)
 		canvas.Draw()
 		drawStatusbar()
 		if helpShown {
 			showHelp()
 		}
-		termbox.Flush()
+		screen.Flush()
 	}
 }
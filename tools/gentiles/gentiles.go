@@ -18,8 +18,18 @@
  */
 package main
 
+// gentiles generates the table of tile glyphs in pkg/termdraw/tiles_gen.go
+// from the names of the Unicode box drawing characters. Run it with
+// "go generate" in pkg/termdraw.
+
 import (
+	"bytes"
+	"flag"
 	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"sort"
 	"strings"
 )
 
@@ -139,35 +149,53 @@ var (
 		"LIGHT UP AND HEAVY DOWN":               '╽',
 		"HEAVY LEFT AND LIGHT RIGHT":            '╾',
 		"HEAVY UP AND LIGHT DOWN":               '╿',
-		/*
-			"LIGHT TRIPLE DASH HORIZONTAL":             '┄',
-			"HEAVY TRIPLE DASH HORIZONTAL":             '┅',
-			"LIGHT TRIPLE DASH VERTICAL":               '┆',
-			"HEAVY TRIPLE DASH VERTICAL":               '┇',
-			"LIGHT QUADRUPLE DASH HORIZONTAL":          '┈',
-			"HEAVY QUADRUPLE DASH HORIZONTAL":          '┉',
-			"LIGHT QUADRUPLE DASH VERTICAL":            '┊',
-			"HEAVY QUADRUPLE DASH VERTICAL":            '┋',
-			"LIGHT DOUBLE DASH HORIZONTAL":             '╌',
-			"HEAVY DOUBLE DASH HORIZONTAL":             '╍',
-			"LIGHT DOUBLE DASH VERTICAL":               '╎',
-			"HEAVY DOUBLE DASH VERTICAL":               '╏',
-			"LIGHT DIAGONAL UPPER RIGHT TO LOWER LEFT": '╱',
-			"LIGHT DIAGONAL UPPER LEFT TO LOWER RIGHT": '╲',
-			"LIGHT DIAGONAL CROSS":                     '╳',
-
-		*/
+		"LIGHT TRIPLE DASH HORIZONTAL":          '┄',
+		"HEAVY TRIPLE DASH HORIZONTAL":          '┅',
+		"LIGHT TRIPLE DASH VERTICAL":            '┆',
+		"HEAVY TRIPLE DASH VERTICAL":            '┇',
+		"LIGHT QUADRUPLE DASH HORIZONTAL":       '┈',
+		"HEAVY QUADRUPLE DASH HORIZONTAL":       '┉',
+		"LIGHT QUADRUPLE DASH VERTICAL":         '┊',
+		"HEAVY QUADRUPLE DASH VERTICAL":         '┋',
+		"LIGHT DOUBLE DASH HORIZONTAL":          '╌',
+		"HEAVY DOUBLE DASH HORIZONTAL":          '╍',
+		"LIGHT DOUBLE DASH VERTICAL":            '╎',
+		"HEAVY DOUBLE DASH VERTICAL":            '╏',
+	}
+
+	// Diagonals, with their arms in the order of diagonalArms
+	diagonalNames = map[string]rune{
+		"LIGHT DIAGONAL UPPER RIGHT TO LOWER LEFT": '╱',
+		"LIGHT DIAGONAL UPPER LEFT TO LOWER RIGHT": '╲',
+		"LIGHT DIAGONAL CROSS":                     '╳',
+	}
+	diagonalArms = map[string]string{
+		"LIGHT DIAGONAL UPPER RIGHT TO LOWER LEFT": " l l",
+		"LIGHT DIAGONAL UPPER LEFT TO LOWER RIGHT": "l l ",
+		"LIGHT DIAGONAL CROSS":                     "llll",
+	}
+
+	// Pattern characters of dashed lines
+	dashes = map[string]byte{
+		"LIGHT DOUBLE":    'b',
+		"HEAVY DOUBLE":    'B',
+		"LIGHT TRIPLE":    't',
+		"HEAVY TRIPLE":    'T',
+		"LIGHT QUADRUPLE": 'q',
+		"HEAVY QUADRUPLE": 'Q',
 	}
 )
 
-func isWeight(s string) bool {
-	return s == "LIGHT" || s == "HEAVY" || s == "DOUBLE"
-}
+// Tile pattern characters of all border styles: light, rounded, heavy,
+// double, and the light and heavy double, triple and quadruple dashes.
+const defaultStyles = "lrhdbBtTqQ"
 
-func splitFirst(s string) (first, remainder string) {
-	parts := strings.SplitN(s, " ", 2)
-	return parts[0], parts[1]
-}
+var (
+	outFile      = flag.String("o", "", "output file; stdout if empty")
+	styles       = flag.String("styles", defaultStyles, "border styles to generate, as tile pattern characters")
+	useDiagonals = flag.Bool("diagonals", false, "generate the diagonal glyphs, whose tile patterns have 8 arms")
+	unmapped     = flag.Bool("unmapped", false, "report tile patterns without a glyph on stderr")
+)
 
 func parsePart(part string, curWeight byte, pattern []byte) byte {
 	elems := strings.Split(part, " ")
@@ -185,6 +213,9 @@ func parsePart(part string, curWeight byte, pattern []byte) byte {
 			curWeight = 'd'
 		}
 	}
+	if len(elems) > 2 && elems[2] == "DASH" {
+		curWeight = dashes[elems[0]+" "+elems[1]]
+	}
 
 	// handle directions
 	for _, e := range elems {
@@ -209,60 +240,216 @@ func parsePart(part string, curWeight byte, pattern []byte) byte {
 	return curWeight
 }
 
-func substituteRound(pattern []byte) []string {
-	var seen = make(map[string]bool)
+func isArc(s string) bool {
+	return s == "  rr" || s == " rr " || s == "rr  " || s == "r  r"
+}
 
+// substituteRound returns pattern, and pattern with any of its light arms
+// replaced by rounded ones: rounded lines look like light ones except in
+// arc corners.
+func substituteRound(pattern []byte) []string {
 	s := string(pattern)
-	if s == "  rr" || s == " rr " || s == "rr  " || s == "r  r" {
+	if isArc(s) {
 		// no need to substitute
-		return []string { s }
+		return []string{s}
 	}
 
-	// all 4 bit combinations, for every bit set, substitute 'l' with 'r'
-	for i := 0; i < 32; i++ {
+	var res []string
+	seen := make(map[string]bool)
+	for i := 0; i < 16; i++ {
 		p := make([]byte, 4)
 		copy(p, pattern)
 
 		for bit := 0; bit < 4; bit++ {
-			if (i & (1<<bit)) == (1<<bit) && p[bit] == 'l' {
+			if (i&(1<<bit)) == (1<<bit) && p[bit] == 'l' {
 				p[bit] = 'r'
 			}
 		}
 		s := string(p)
-		if s == "  rr" || s == " rr " || s == "rr  " || s == "r  r" {
-			// Not a valid substitution
+		if isArc(s) || seen[s] {
+			// Not a valid substitution, or already there
 			continue
 		}
 		seen[s] = true
+		res = append(res, s)
+	}
+	return res
+}
+
+// parse returns the tile patterns of the glyph with the given name.
+func parse(name string) []string {
+	pattern := []byte{' ', ' ', ' ', ' '}
+
+	var curWeight byte = 'l'
+	parts := strings.Split(name, " AND ")
+	for _, p := range parts {
+		curWeight = parsePart(p, curWeight, pattern)
 	}
 
+	return substituteRound(pattern)
+}
+
+// generate returns the glyphs of all tile patterns that only use the given
+// styles. With diagonals, the diagonal glyphs are added with patterns of 8
+// arms: left, up, right, down, up-left, up-right, down-right and down-left.
+func generate(styles string, diagonals bool) map[string]rune {
+	valid := func(p string) bool {
+		for _, c := range []byte(p) {
+			if c != ' ' && strings.IndexByte(styles, c) < 0 {
+				return false
+			}
+		}
+		return true
+	}
+
+	res := make(map[string]rune)
+	for key, val := range names {
+		for _, p := range parse(key) {
+			if valid(p) {
+				res[p] = val
+			}
+		}
+	}
+	if diagonals {
+		for key, val := range diagonalNames {
+			p := "    " + diagonalArms[key]
+			if valid(p) {
+				res[p] = val
+			}
+		}
+	}
+	return res
+}
 
+func sortedPatterns(tiles map[string]rune) []string {
 	var res []string
-	for key, _ := range seen {
-		res = append(res, key)
+	for p := range tiles {
+		res = append(res, p)
 	}
+	sort.Strings(res)
 	return res
 }
 
+// preferred tells whether pattern a is a better canonical tile than b for
+// a glyph they share. Rounded arms only make a difference in arc corners,
+// which have a glyph of their own, so light arms win. Ties are broken by
+// pattern order.
+func preferred(a, b string) bool {
+	ra, rb := strings.Count(a, "r"), strings.Count(b, "r")
+	if ra != rb {
+		return ra < rb
+	}
+	return a < b
+}
+
+// reverse returns the canonical tile pattern for every glyph.
+func reverse(tiles map[string]rune) map[rune]string {
+	res := make(map[rune]string)
+	for p, r := range tiles {
+		if cur, ok := res[r]; !ok || preferred(p, cur) {
+			res[r] = p
+		}
+	}
+	return res
+}
 
+// missing returns all combinations of the orthogonal arms in the given
+// styles that have no glyph.
+func missing(tiles map[string]rune, styles string) []string {
+	chars := " " + styles
+	var res []string
+	var rec func(prefix string)
+	rec = func(prefix string) {
+		if len(prefix) == 4 {
+			if _, ok := tiles[prefix]; !ok && strings.TrimSpace(prefix) != "" {
+				res = append(res, prefix)
+			}
+			return
+		}
+		for _, c := range chars {
+			rec(prefix + string(c))
+		}
+	}
+	rec("")
+	return res
+}
 
-func parse(name string, ch rune) {
-	pattern := []byte{' ', ' ', ' ', ' '}
+// render returns the source of tiles_gen.go.
+func render(tiles map[string]rune) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(license)
+	buf.WriteString("// Code generated by gentiles; DO NOT EDIT.\n\n")
+	buf.WriteString("package termdraw\n\n")
 
-	var curWeight byte = 'l'
-	parts := strings.Split(name, " AND ")
-	for _, p := range parts {
-		curWeight = parsePart(p, curWeight, pattern)
+	buf.WriteString("// tileToRune maps tiles to their glyphs.\n")
+	buf.WriteString("var tileToRune = map[Tile]rune{\n")
+	for _, p := range sortedPatterns(tiles) {
+		fmt.Fprintf(&buf, "newTile(%q): '%c',\n", p, tiles[p])
 	}
+	buf.WriteString("}\n\n")
 
-	patterns := substituteRound(pattern);
-	for _, p := range patterns {
-		fmt.Printf("newTile(\"%s\"): '%c',\n", p, ch)
+	rev := reverse(tiles)
+	var runes []rune
+	for r := range rev {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	buf.WriteString("// runeToTile maps glyphs to their canonical tiles: where several tiles share\n")
+	buf.WriteString("// a glyph, light arms are preferred over rounded ones.\n")
+	buf.WriteString("var runeToTile = map[rune]Tile{\n")
+	for _, r := range runes {
+		fmt.Fprintf(&buf, "'%c': newTile(%q),\n", r, rev[r])
 	}
+	buf.WriteString("}\n")
+
+	return format.Source(buf.Bytes())
 }
 
+const license = `/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+`
+
 func main() {
-	for key, val := range names {
-		parse(key, val)
+	flag.Parse()
+
+	tiles := generate(*styles, *useDiagonals)
+	src, err := render(tiles)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *unmapped {
+		m := missing(tiles, *styles)
+		fmt.Fprintf(os.Stderr, "%d tile patterns without a glyph:\n", len(m))
+		for _, p := range m {
+			fmt.Fprintf(os.Stderr, "%q\n", p)
+		}
+	}
+
+	if *outFile == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err := ioutil.WriteFile(*outFile, src, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 }
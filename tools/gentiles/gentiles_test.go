@@ -0,0 +1,142 @@
+/*
+ * Copyright (c) 2022 Andreas Signer <asigner@gmail.com>
+ *
+ * This file is part of termdraw.
+ *
+ * termdraw is free software: you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * termdraw is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with termdraw.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestGeneratedMatchesCommitted(t *testing.T) {
+	committed, err := ioutil.ReadFile("../../pkg/termdraw/tiles_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := render(generate(defaultStyles, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(generated, committed) {
+		t.Errorf("tiles_gen.go is out of date, run go generate in pkg/termdraw")
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	first, err := render(generate("lrhd", false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		src, err := render(generate("lrhd", false))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(src, first) {
+			t.Fatalf("output differs between runs")
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"LIGHT HORIZONTAL", []string{"l l ", "r l ", "l r ", "r r "}},
+		{"DOUBLE DOWN AND RIGHT", []string{"  dd"}},
+		{"DOWN LIGHT AND LEFT UP HEAVY", []string{"hh l", "hh r"}},
+		{"LIGHT ARC UP AND LEFT", []string{"rr  "}},
+	}
+	for _, tc := range tests {
+		got := make(map[string]bool)
+		for _, p := range parse(tc.name) {
+			got[p] = true
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("parse(%q) = %v, want %v", tc.name, got, tc.want)
+			continue
+		}
+		for _, p := range tc.want {
+			if !got[p] {
+				t.Errorf("parse(%q) = %v, want %v", tc.name, got, tc.want)
+				break
+			}
+		}
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	rev := reverse(generate("lrhd", false))
+	for r, want := range map[rune]string{
+		'│': " l l",
+		'─': "l l ",
+		'┼': "llll",
+		'┿': "hlhl",
+		'╭': "  rr",
+		'╯': "rr  ",
+	} {
+		if got := rev[r]; got != want {
+			t.Errorf("canonical tile of %c is %q, want %q", r, got, want)
+		}
+	}
+}
+
+func TestDashesAndDiagonals(t *testing.T) {
+	tiles := generate("lrhdbBtTqQ", true)
+	for p, want := range map[string]rune{
+		"t t ":     '┄',
+		" Q Q":     '┋',
+		"b b ":     '╌',
+		"     l l": '╱',
+		"    l l ": '╲',
+		"    llll": '╳',
+		"l l ":     '─',
+	} {
+		if got := tiles[p]; got != want {
+			t.Errorf("pattern %q is %c, want %c", p, got, want)
+		}
+	}
+
+	// Without the styles, they are left out
+	for p := range generate("lrhd", false) {
+		if len(p) != 4 {
+			t.Errorf("pattern %q has diagonals", p)
+		}
+	}
+	if _, ok := generate("lrhd", false)["t t "]; ok {
+		t.Errorf("dashed pattern generated without its style")
+	}
+}
+
+func TestMissing(t *testing.T) {
+	m := missing(generate("lrhd", false), "lrhd")
+	found := false
+	for _, p := range m {
+		if p == "hdh " {
+			found = true
+		}
+		if p == "l l " || p == "    " {
+			t.Errorf("pattern %q reported as missing", p)
+		}
+	}
+	if !found {
+		t.Errorf("missing pattern \"hdh \" not reported")
+	}
+}